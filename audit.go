@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"pass-inator/passinator"
+)
+
+// auditRecord is one line of the audit log. It describes how a password was
+// generated but holds nothing derived from the password itself, so the log
+// cannot be used to reconstruct it
+type auditRecord struct {
+	Time    time.Time                 `json:"time"`
+	Config  passinator.PasswordConfig `json:"config"`
+	Entropy float64                   `json:"entropy"`
+}
+
+// writeAudit writes a JSON line to w recording that a password was generated
+// now with config and the given estimated entropy
+func writeAudit(w io.Writer, config passinator.PasswordConfig, entropy float64) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(auditRecord{
+		Time:    time.Now().UTC(),
+		Config:  config,
+		Entropy: roundEntropy(entropy),
+	})
+}
+
+// appendAudit appends an audit record to the log file at path, creating it
+// readable only by the owner
+func appendAudit(path string, config passinator.PasswordConfig, entropy float64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := writeAudit(f, config, entropy); err != nil {
+		return err
+	}
+	return f.Close()
+}
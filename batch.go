@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"pass-inator/passinator"
+)
+
+// generatePasswordsCtx generates config.Count passwords in the background and
+// streams them on the returned channel, which is closed once generation ends.
+// The error channel then receives a single value: nil on success, the
+// generation error, or ctx.Err() when ctx was cancelled first
+func generatePasswordsCtx(ctx context.Context, config passinator.PasswordConfig) (<-chan string, <-chan error) {
+	results := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(results)
+
+		if err := passinator.ValidateBatch(config, config.Count); err != nil {
+			errs <- err
+			return
+		}
+
+		seen := make(map[string]bool)
+		for attempts, sent := 0, 0; sent < config.Count; attempts++ {
+			if err := ctx.Err(); err != nil {
+				errs <- err
+				return
+			}
+			if attempts >= passinator.BatchAttempts(config, config.Count) {
+				errs <- fmt.Errorf("could only generate %d distinct passwords out of %d requested: %w", sent, config.Count, passinator.ErrGenerationExhausted)
+				return
+			}
+			password, err := passinator.GeneratePassword(config)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if config.Unique {
+				if seen[password] {
+					continue
+				}
+				seen[password] = true
+			}
+			select {
+			case results <- password:
+				sent++
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		errs <- nil
+	}()
+
+	return results, errs
+}
+
+// collectPasswords gathers the passwords streamed by generatePasswordsCtx,
+// reporting the count to prog. When ctx is cancelled the passwords generated
+// so far are returned along with the context error, so that they can still
+// be written out
+func collectPasswords(ctx context.Context, config passinator.PasswordConfig, prog *progress) ([]string, error) {
+	results, errs := generatePasswordsCtx(ctx, config)
+	var passwords []string
+	for password := range results {
+		passwords = append(passwords, password)
+		prog.update(len(passwords))
+	}
+	prog.finish(len(passwords))
+	return passwords, <-errs
+}
+
+// generateBatch calls next until it has count values, all distinct when
+// unique is set. Duplicates are drawn again, but after attempts calls in all
+// it gives up with passinator.ErrGenerationExhausted, so that a keyspace too
+// small for a unique batch fails instead of spinning
+func generateBatch(count int, unique bool, attempts int, next func() (string, error)) ([]string, error) {
+	values := make([]string, 0, count)
+	seen := make(map[string]bool)
+	for tries := 0; len(values) < count; tries++ {
+		if tries >= attempts {
+			return nil, fmt.Errorf("could only generate %d distinct values out of %d requested: %w", len(values), count, passinator.ErrGenerationExhausted)
+		}
+		value, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if unique {
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// checkUniqueKeyspace fails when count distinct values cannot be drawn from
+// a keyspace of bits of entropy, the check passinator.ValidateBatch makes for
+// a unique batch of passwords. what names the values in the error
+func checkUniqueKeyspace(count int, bits float64, what string) error {
+	if math.Log2(float64(count)) > bits {
+		return fmt.Errorf("cannot generate %d distinct %s: only about %.0f are possible", count, what, math.Exp2(bits))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"pass-inator/passinator"
+)
+
+// checkCommand implements the check command, which validates the password
+// given as its argument (or read from stdin) against a policy
+func checkCommand(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	policy := addPolicyFlags(fs)
+	minEntropy := fs.Float64("min-entropy", 0, fmt.Sprintf("exit with status %d if the observed entropy is below this many `bits`", exitPolicy))
+	jsonOutput := fs.Bool("json", false, "print the result as a JSON object with ok, failures, entropy and breakdown")
+	fs.Usage = commandUsage(fs, "[password]", "Checks an existing password against the policy given by the flags. The\npassword is read from stdin when it is - or omitted.")
+	fs.Parse(args)
+
+	if err := policy.configFromEnv(fs); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if fs.NArg() > 1 {
+		fmt.Println("Error: check takes a single password")
+		os.Exit(exitError)
+	}
+	password := "-"
+	if fs.NArg() == 1 {
+		password = fs.Arg(0)
+	}
+
+	config, err := policy.config(fs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	runValidate(password, policy.forChecking(fs, config), *minEntropy, *jsonOutput)
+}
+
+// runValidate checks an existing password against policy and exits with
+// exitPolicy when it fails. A password of "-" is read from stdin, without
+// echo when it is a terminal. With asJSON the passinator.PolicyResult is
+// printed instead of the report
+func runValidate(password string, policy passinator.PasswordConfig, minEntropy float64, asJSON bool) {
+	if password == "-" {
+		var err error
+		password, err = readSecret("Password: ")
+		if err != nil {
+			fmt.Printf("Error reading password: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	result := passinator.CheckPolicy(password, policy)
+	if result.Entropy < minEntropy {
+		result.Failures = append(result.Failures, fmt.Sprintf("needs >=%.1f bits of entropy, found %.1f", minEntropy, result.Entropy))
+		result.OK = false
+	}
+
+	if asJSON {
+		result.Entropy = roundEntropy(result.Entropy)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(result); err != nil {
+			fmt.Printf("Error encoding JSON: %v\n", err)
+			os.Exit(exitError)
+		}
+	} else {
+		printPolicyReport(password, result)
+	}
+	if !result.OK {
+		os.Exit(exitPolicy)
+	}
+}
+
+// printPolicyReport prints the character breakdown and strength of password
+// followed by the outcome of the policy check in result
+func printPolicyReport(password string, result passinator.PolicyResult) {
+	fmt.Println(formatBreakdown(password))
+	fmt.Printf("Entropy: %.1f bits (%s)\n", result.Entropy, strengthLabel(result.Entropy))
+	if result.OK {
+		fmt.Println("PASS: password satisfies the policy")
+		return
+	}
+	fmt.Println("FAIL: password does not satisfy the policy:")
+	for _, failure := range result.Failures {
+		fmt.Printf("  - %s\n", failure)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands lists the commands tried, in order, to write to the
+// system clipboard on each platform
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip.exe"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// copyToClipboard writes s to the system clipboard using the first available
+// platform clipboard tool
+func copyToClipboard(s string) error {
+	candidates, ok := clipboardCommands[runtime.GOOS]
+	if !ok {
+		return fmt.Errorf("clipboard is not supported on %s", runtime.GOOS)
+	}
+	// Zeroed once the tool has read it, as in writeToFile
+	data := []byte(s)
+	defer clear(data)
+
+	for _, args := range candidates {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w", args[0], err)
+		}
+		return nil
+	}
+
+	var names []string
+	for _, args := range candidates {
+		names = append(names, args[0])
+	}
+	return fmt.Errorf("no clipboard tool found (tried %s)", strings.Join(names, ", "))
+}
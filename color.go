@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences used by colorize
+const (
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiGreen  = "\033[32m"
+	ansiReset  = "\033[0m"
+)
+
+// strengthColors maps the passinator.StrengthLabel values to their colors
+var strengthColors = map[string]string{
+	"Weak":        ansiRed,
+	"Fair":        ansiYellow,
+	"Strong":      ansiGreen,
+	"Very Strong": ansiGreen,
+}
+
+// colorEnabled reports whether output may be colored: stdout must be a
+// terminal and NO_COLOR unset or empty, following https://no-color.org
+func colorEnabled() bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorize wraps label in the color of the strength level, a
+// passinator.StrengthLabel value, when colors are enabled. Otherwise, or for
+// an unknown level, label is returned unchanged
+func colorize(label, level string) string {
+	color, ok := strengthColors[level]
+	if !ok || !colorEnabled() {
+		return label
+	}
+	return color + label + ansiReset
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"pass-inator/passinator"
+)
+
+// csvHeader returns the header row of -csv output: the index, password,
+// length and entropy of every password, then its hash when hashed and its
+// breakdown when verbose
+func csvHeader(hashed, verbose bool) []string {
+	header := []string{"index", "password", "length", "entropy"}
+	if hashed {
+		header = append(header, "hash")
+	}
+	if verbose {
+		header = append(header, csvBreakdown...)
+	}
+	return header
+}
+
+// csvBreakdown lists the passinator.AnalyzePassword categories in the order
+// of their -verbose columns
+var csvBreakdown = []string{
+	passinator.CategoryLowercase,
+	passinator.CategoryUppercase,
+	passinator.CategoryDigits,
+	passinator.CategorySpecial,
+}
+
+// printCSV writes passwords as CSV with a header row, numbering them from 1,
+// with hashes[i] as the hash of passwords[i] when hashes is not nil.
+// encoding/csv quotes any password containing a comma, a quote or a line
+// break, so every row parses back to the original password
+func printCSV(passwords, hashes []string, entropy float64, verbose bool, opts outputOptions) error {
+	return withRecordOutput(opts, func(w io.Writer) error {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader(hashes != nil, verbose)); err != nil {
+			return err
+		}
+		bits := strconv.FormatFloat(roundEntropy(entropy), 'f', 1, 64)
+		for i, password := range passwords {
+			row := []string{strconv.Itoa(i + 1), password, strconv.Itoa(utf8.RuneCountInString(password)), bits}
+			if hashes != nil {
+				row = append(row, hashes[i])
+			}
+			if verbose {
+				counts := passinator.AnalyzePassword(password)
+				for _, category := range csvBreakdown {
+					row = append(row, strconv.Itoa(counts[category]))
+				}
+			}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("failed to write row %d: %w", i+1, err)
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
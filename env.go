@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// envNamePattern matches valid POSIX shell variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// shellQuote wraps s in single quotes for a POSIX shell. A single quote cannot
+// appear inside single quotes, so each one closes the quoted string, adds an
+// escaped quote and reopens it:
+//
+//	it's -> 'it'\''s'
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envExports formats values as shell export statements for name. A single
+// value is exported as name itself; several are numbered name_1, name_2, ...
+func envExports(name string, values []string) []string {
+	exports := make([]string, 0, len(values))
+	for i, value := range values {
+		varName := name
+		if len(values) > 1 {
+			varName = fmt.Sprintf("%s_%d", name, i+1)
+		}
+		exports = append(exports, fmt.Sprintf("export %s=%s", varName, shellQuote(value)))
+	}
+	return exports
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// clearScreen moves the cursor home, clears the screen and then the
+// scrollback buffer, which most terminal emulators support as \033[3J
+const clearScreen = "\033[H\033[2J\033[3J"
+
+// clearEphemeral clears the terminal opts.ephemeral seconds after results
+// were shown on it with printResults. It is called once everything about
+// the results is written too, since the ambiguous-character warning quotes
+// characters of the password
+func clearEphemeral(opts outputOptions) {
+	if opts.ephemeral > 0 && opts.outPath == "" && !opts.clipboard && opts.store == "" {
+		clearAfter(opts.ephemeral, opts.quiet)
+	}
+}
+
+// clearAfter waits the given number of seconds and then clears the terminal,
+// so that a displayed password does not linger on screen. Ctrl-C clears it
+// at once and exits with exitInterrupted. Nothing happens when stdout is not
+// a terminal, since there is no screen to clear
+func clearAfter(seconds int, quiet bool) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+	delay := time.Duration(seconds) * time.Second
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Clearing the screen in %v (Ctrl-C to clear now)\n", delay)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		fmt.Print(clearScreen)
+	case <-interrupt:
+		fmt.Print(clearScreen)
+		os.Exit(exitInterrupted)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"pass-inator/passinator"
+)
+
+// openOutputFile opens path for writing the results to, creating it readable
+// only by the owner. Output is appended unless truncate is set
+func openOutputFile(path string, truncate bool) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if truncate {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	return os.OpenFile(path, flags, 0600)
+}
+
+// writeToFile appends each value to the file at path on its own line,
+// creating it readable only by the owner. When truncate is set any existing
+// content is discarded first. The data is synced to disk before returning
+func writeToFile(path string, values []string, truncate bool) error {
+	f, err := openOutputFile(path, truncate)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Build the output in a buffer we own so it can be zeroed afterwards; a
+	// bufio.Writer would keep its own copy. This is best effort, since the
+	// values themselves are strings and cannot be wiped
+	size := 0
+	for _, value := range values {
+		size += len(value) + 1
+	}
+	// Sized up front so that appending never leaves a stale copy behind
+	buf := make([]byte, 0, size)
+	for _, value := range values {
+		buf = append(buf, value...)
+		buf = append(buf, '\n')
+	}
+	defer clear(buf)
+	if _, err := f.Write(buf); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// streamToFile generates config.Count passwords straight into the file at
+// path, one per line, without holding the batch in memory, reporting the
+// count written to prog. When ctx is cancelled the passwords generated so far
+// are still written and synced before ctx.Err() is returned
+func streamToFile(ctx context.Context, path string, config passinator.PasswordConfig, truncate bool, prog *progress) error {
+	f, err := openOutputFile(path, truncate)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := &progressWriter{w: f, p: prog}
+	genErr := passinator.WritePasswordsContext(ctx, w, config, config.Count, "\n")
+	prog.finish(w.count)
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return genErr
+}
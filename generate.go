@@ -0,0 +1,715 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"pass-inator/passinator"
+)
+
+// generateCommand implements the generate command, which is also run when no
+// command is given. The passphrase, token and check commands remain reachable
+// through the -passphrase, -token and -validate flags for compatibility
+func generateCommand(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	policy := addPolicyFlags(fs)
+	count := fs.Int("count", 1, "number of passwords to generate")
+	unique := fs.Bool("unique", false, "with -count, make every password in the batch distinct")
+	passphrase := fs.Bool("passphrase", false, "generate a diceware-style passphrase instead of a password (same as the passphrase command)")
+	phrase := addPassphraseFlags(fs)
+	pin := fs.Bool("pin", false, fmt.Sprintf("generate a numeric PIN (-length defaults to %d, minimum %d)", defaultPINLength, passinator.MinPINLength))
+	pronounceable := fs.Bool("pronounceable", false, "generate a pronounceable password made of syllables")
+	syllables := fs.Int("syllables", 4, "number of syllables in a pronounceable password")
+	withDigit := fs.Bool("with-digit", false, "insert a random digit into a pronounceable password")
+	token := fs.Bool("token", false, "generate a random token of -bytes bytes (same as the token command)")
+	tokenOpts := addTokenFlags(fs)
+	pattern := fs.String("pattern", "", "generate from a `pattern` (word/Word=wordlist word, A=upper, a=lower, #=digit, $=special, others literal)")
+	guessRate := fs.Float64("guess-rate", passinator.DefaultGuessesPerSecond, "attacker guesses per second assumed by the crack time estimate")
+	site := fs.String("site", "", "derive a reproducible password for `site` from a master password read on stdin")
+	anchor := fs.String("anchor", "", "embed this `word` literally in the password, filling the rest of -length with random characters (the word adds no strength)")
+	anchorPos := fs.String("anchor-pos", passinator.AnchorEnd, "where -anchor goes: "+strings.Join(passinator.AnchorPositions, ", "))
+	checkPwnedFlag := fs.Bool("check-pwned", false, "check passwords against Have I Been Pwned and regenerate breached ones")
+	pwnedTimeout := fs.Duration("pwned-timeout", 5*time.Second, "with -check-pwned, HTTP timeout for each lookup")
+	validate := fs.String("validate", "", "check an existing `password` (or - to read it from stdin) against the policy given by the other flags (same as the check command)")
+	auditPath := fs.String("audit", "", "append a JSON line with the time, settings and entropy of each run to `file`; the password is never logged")
+	match := fs.String("match", "", "only output passwords matching the regular `expression` (Go RE2 syntax, no lookaheads)")
+	charsetSize := fs.Bool("charset-size", false, "print how many distinct characters the settings draw from, after exclusions, without generating a password")
+	entropyOnly := fs.Bool("entropy-only", false, "print the estimated entropy of the settings without generating a password")
+	targetEntropy := fs.Float64("target-entropy", 0, "use the shortest length that reaches this many `bits` of entropy, instead of -length (with -pronounceable, the fewest syllables instead of -syllables)")
+	hashAlgo := fs.String("hash", "", "also print a hash of each password for storage on a server: bcrypt or argon2 (Argon2id)")
+	minEntropy := fs.Float64("min-entropy", 0, fmt.Sprintf("exit with status %d if the estimated entropy is below this many `bits`", exitPolicy))
+	opts := addOutputFlags(fs)
+	selfTest := addSelfTestFlag(fs)
+	noAmbiguousWarning := fs.Bool("no-ambiguous-warning", false, "do not warn when a password contains easily confused characters ("+passinator.AmbiguousChars+")")
+	verbose := fs.Bool("verbose", false, "show how many characters of each type every password contains")
+	printConfig := fs.Bool("print-config", false, "write the resolved password settings to stderr as JSON that -config accepts, without the password")
+	jsonOutput := fs.Bool("json", false, "print the result as JSON (an array when -count is given)")
+	jsonl := fs.Bool("jsonl", false, "print one JSON object with index, password and entropy per line (JSON Lines), each as soon as it is generated")
+	menu := fs.Bool("menu", false, "choose from a numbered menu to generate passwords, passphrases and tokens, check a password or change settings, until you quit")
+	csvOutput := fs.Bool("csv", false, "print the result as CSV with a header row and index, password, length and entropy columns")
+	fs.Usage = rootUsage(fs)
+	fs.Parse(args)
+
+	prepareOutputOptions(opts)
+	runSelfTest(*selfTest, *opts)
+	if *jsonOutput && (opts.clipboard || opts.qr || opts.envName != "") {
+		fmt.Println("Error: -json cannot be combined with -clipboard, -qr or -env")
+		os.Exit(exitError)
+	}
+	if *jsonl && (*jsonOutput || opts.clipboard || opts.qr || opts.envName != "") {
+		fmt.Println("Error: -jsonl cannot be combined with -json, -clipboard, -qr or -env")
+		os.Exit(exitError)
+	}
+	if opts.ephemeral > 0 && (*jsonOutput || *jsonl || *csvOutput) {
+		fmt.Println("Error: -ephemeral cannot be combined with -json, -jsonl or -csv")
+		os.Exit(exitError)
+	}
+	if *csvOutput && (*jsonOutput || *jsonl || opts.clipboard || opts.qr || opts.envName != "") {
+		fmt.Println("Error: -csv cannot be combined with -json, -jsonl, -clipboard, -qr or -env")
+		os.Exit(exitError)
+	}
+	if opts.store != "" && (*jsonOutput || *jsonl || *csvOutput) {
+		fmt.Println("Error: -store cannot be combined with -json, -jsonl or -csv")
+		os.Exit(exitError)
+	}
+	if *hashAlgo != "" {
+		if err := validateHashAlgorithm(*hashAlgo); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	// The other generators print their results themselves, so flags that
+	// only apply to passwords are refused with them instead of ignored
+	if mode := generatorMode(*passphrase, phrase.memorable, phrase.wordNumberWord, *pin, *token, *pattern != "", *pronounceable); mode != "" {
+		passwordOnly := []struct {
+			name string
+			set  bool
+		}{
+			{"min-entropy", *minEntropy > 0},
+			{"json", *jsonOutput},
+			{"jsonl", *jsonl},
+			{"csv", *csvOutput},
+			{"hash", *hashAlgo != ""},
+			{"audit", *auditPath != ""},
+			// Breached values would be printed without a warning
+			{"check-pwned", *checkPwnedFlag},
+			// A fresh random value could never be derived again
+			{"site", *site != ""},
+			{"match", *match != ""},
+			{"anchor", *anchor != ""},
+			// PIN and token batches can be made distinct, and pronounceable
+			// passwords have their own use of -target-entropy
+			{"unique", *unique && mode != "pin" && mode != "token"},
+			{"target-entropy", *targetEntropy > 0 && mode != "pronounceable"},
+		}
+		for _, f := range passwordOnly {
+			if f.set {
+				fmt.Printf("Error: -%s cannot be combined with -%s\n", f.name, mode)
+				os.Exit(exitError)
+			}
+		}
+	}
+
+	if *passphrase || phrase.memorable || phrase.wordNumberWord {
+		phrase.run(fs, *count, *guessRate, *opts)
+		return
+	}
+
+	if *pin {
+		pinLength := defaultPINLength
+		if isFlagSet(fs, "length") {
+			if policy.values.MaxLength > 0 {
+				fmt.Println("Error: -pin does not accept a -length range")
+				os.Exit(exitError)
+			}
+			pinLength = policy.values.Length
+		}
+		runPIN(pinLength, *count, *unique, passinator.BatchAttempts(policy.values, *count), *opts)
+		return
+	}
+
+	if *token {
+		runToken(tokenOpts.bytes, tokenOpts.encoding, *count, *unique, passinator.BatchAttempts(policy.values, *count), *opts)
+		return
+	}
+
+	if *pattern != "" {
+		runPattern(*pattern, phrase.config.Wordlist, *count, *opts)
+		return
+	}
+
+	if *pronounceable {
+		config := passinator.PronounceableConfig{
+			Syllables:    *syllables,
+			IncludeDigit: *withDigit,
+		}
+		if *targetEntropy > 0 {
+			config.Syllables = passinator.PronounceableSyllablesFor(*targetEntropy, config)
+			if !opts.quiet {
+				fmt.Fprintf(os.Stderr, "Using %d syllables to reach %.0f bits.\n", config.Syllables, *targetEntropy)
+			}
+		}
+		runPronounceable(config, *count, *guessRate, *opts)
+		return
+	}
+
+	if err := policy.configFromEnv(fs); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	if *menu {
+		config, err := policy.config(fs)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		config.Count = 1
+		runMenu(menuSettings{
+			password:      config,
+			passphrase:    phrase.config,
+			tokenBytes:    tokenOpts.bytes,
+			tokenEncoding: tokenOpts.encoding,
+			forChecking: func(config passinator.PasswordConfig) passinator.PasswordConfig {
+				return policy.forChecking(fs, config)
+			},
+			noAmbiguousWarning: *noAmbiguousWarning,
+		}, *guessRate, *opts)
+		return
+	}
+
+	// Only prompt when no flags or PASSINATOR_* variables were given, so
+	// scripts never block on stdin, and when the prompts can be seen
+	interactive := fs.NFlag() == 0 && !opts.quiet
+
+	var config passinator.PasswordConfig
+	if interactive {
+		var err error
+		if config, err = promptConfig(); err != nil {
+			if errors.Is(err, errInputClosed) {
+				fmt.Println("Cancelled: no more input")
+			} else {
+				fmt.Printf("Error reading input: %v\n", err)
+			}
+			os.Exit(exitError)
+		}
+	} else {
+		var err error
+		// -target-entropy replaces the length, so the configuration is only
+		// validated once it has
+		config, err = policy.unvalidated(fs)
+		if err == nil && *targetEntropy <= 0 {
+			err = policy.validate(config)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if policy.preset == "nist" && !isFlagSet(fs, "check-pwned") {
+			// SP 800-63B requires screening against known breached passwords
+			*checkPwnedFlag = true
+		}
+		if isFlagSet(fs, "count") {
+			config.Count = *count
+		}
+		if isFlagSet(fs, "unique") {
+			config.Unique = *unique
+		}
+		if *targetEntropy > 0 {
+			if config.MaxLength > 0 {
+				fmt.Println("Error: -target-entropy cannot be combined with a -length range")
+				os.Exit(exitError)
+			}
+			if err := applyTargetEntropy(&config, *targetEntropy); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if err := policy.validate(config); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			if !opts.quiet {
+				fmt.Fprintf(os.Stderr, "Using length %d to reach %.0f bits.\n", config.Length, *targetEntropy)
+			}
+		}
+	}
+
+	if *printConfig {
+		if err := writeConfig(os.Stderr, config); err != nil {
+			fmt.Printf("Error encoding JSON: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	if isFlagSet(fs, "validate") {
+		runValidate(*validate, policy.forChecking(fs, config), *minEntropy, *jsonOutput)
+		return
+	}
+
+	if *charsetSize {
+		fmt.Printf("Character set size: %d\n", passinator.CharsetSize(config))
+		return
+	}
+
+	entropy := passinator.EstimateEntropy(config)
+	if *anchor != "" {
+		if *site != "" || *match != "" {
+			fmt.Println("Error: -anchor cannot be combined with -site or -match")
+			os.Exit(exitError)
+		}
+		var err error
+		if entropy, err = passinator.AnchoredEntropy(config, *anchor, *anchorPos); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	if *entropyOnly {
+		if err := printEntropy(entropy, *guessRate, *jsonOutput); err != nil {
+			fmt.Printf("Error encoding JSON: %v\n", err)
+			os.Exit(exitError)
+		}
+		if entropy < *minEntropy {
+			os.Exit(exitPolicy)
+		}
+		return
+	}
+
+	var matchRE *regexp.Regexp
+	if *match != "" {
+		if *site != "" {
+			// A derived password is fixed, so it cannot be re-rolled to match
+			fmt.Println("Error: -site and -match cannot be combined")
+			os.Exit(exitError)
+		}
+		var err error
+		if matchRE, err = regexp.Compile(*match); err != nil {
+			fmt.Printf("Error: invalid -match expression (lookaheads such as (?=...) are not supported; use the -min-* flags instead): %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	// Checked before generating, so that nothing is written for settings
+	// that are too weak
+	if entropy < *minEntropy {
+		fmt.Fprintf(os.Stderr, "Error: estimated entropy %.1f bits is below the required %.1f bits\n", entropy, *minEntropy)
+		os.Exit(exitPolicy)
+	}
+
+	// A batch written to a file that nothing else needs to see is streamed
+	// there, so that even millions of passwords take little memory. JSON
+	// Lines are streamed wherever they go, unless every password is needed
+	// before any can be written
+	plain := *site == "" && matchRE == nil && *anchor == "" && !*checkPwnedFlag && *hashAlgo == ""
+	streamJSONLines := *jsonl && plain
+	stream := opts.outPath != "" && config.Count > 1 && plain && !*jsonl && !*jsonOutput && !*csvOutput && !*verbose &&
+		!opts.clipboard && !opts.qr && opts.envName == ""
+
+	// Generate and display passwords
+	var passwords []string
+	var interrupted bool
+	var err error
+	if *site != "" {
+		passwords, err = deterministicPassword(*site, config)
+	} else if matchRE != nil {
+		passwords, err = generateAllMatching(config, matchRE)
+	} else if *anchor != "" {
+		passwords, err = generateAnchored(config, *anchor, *anchorPos)
+	} else {
+		// Ctrl-C stops a long batch but keeps what was generated so far
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		prog := newProgress(config.Count, *opts)
+		if streamJSONLines {
+			err = streamJSONL(ctx, config, entropy, *verbose, *opts, prog)
+		} else if stream {
+			err = streamToFile(ctx, opts.outPath, config, opts.truncate, prog)
+		} else {
+			passwords, err = collectPasswords(ctx, config, prog)
+		}
+		stop()
+		if errors.Is(err, context.Canceled) {
+			interrupted, err = true, nil
+		}
+	}
+	if err == nil && *checkPwnedFlag {
+		attempts := passinator.AttemptLimit(config)
+		if *site != "" {
+			// A derived password is fixed, so it can only be reported
+			attempts = 0
+		}
+		// Replacements are made the way the batch was, so that they still
+		// match -match and -anchor
+		regenerate := func() (string, error) { return passinator.GeneratePassword(config) }
+		if matchRE != nil {
+			regenerate = func() (string, error) { return generateMatching(config, matchRE) }
+		} else if *anchor != "" {
+			regenerate = func() (string, error) { return passinator.GenerateAnchored(config, *anchor, *anchorPos) }
+		}
+		client := &http.Client{Timeout: *pwnedTimeout}
+		passwords, err = replacePwned(client, passwords, regenerate, config.Unique, attempts)
+	}
+	if err != nil {
+		exitGenerationError(err)
+	}
+	if interrupted && (stream || streamJSONLines) {
+		if opts.outPath != "" {
+			fmt.Fprintf(os.Stderr, "Interrupted; the passwords generated so far were written to %s\n", opts.outPath)
+		} else {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+		}
+		os.Exit(exitInterrupted)
+	}
+	if interrupted {
+		fmt.Fprintf(os.Stderr, "Interrupted after %d of %d passwords\n", len(passwords), config.Count)
+		if len(passwords) == 0 {
+			os.Exit(exitInterrupted)
+		}
+		// Still output the partial batch, so -out gets everything generated
+		defer os.Exit(exitInterrupted)
+	}
+
+	if *anchor != "" && !opts.quiet {
+		warnAnchor(*anchor, passwords)
+	}
+	if *auditPath != "" {
+		if err := appendAudit(*auditPath, config, entropy); err != nil {
+			fmt.Printf("Error writing audit log: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+	if stream || streamJSONLines {
+		if !opts.quiet {
+			if opts.outPath != "" {
+				fmt.Printf("Wrote %d passwords to %s\n", config.Count, opts.outPath)
+			}
+			fmt.Fprintln(os.Stderr, formatEntropy(entropy, *guessRate))
+		}
+		return
+	}
+	var hashes []string
+	if *hashAlgo != "" {
+		if hashes, err = hashPasswords(passwords, *hashAlgo); err != nil {
+			fmt.Printf("Error hashing password: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+	if *jsonl {
+		if err := printJSONL(passwords, hashes, entropy, *verbose, *opts); err != nil {
+			fmt.Printf("Error writing JSON Lines: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+	if *csvOutput {
+		if err := printCSV(passwords, hashes, entropy, *verbose, *opts); err != nil {
+			fmt.Printf("Error writing CSV: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+	if *jsonOutput {
+		if err := printJSON(passwords, hashes, entropy, isFlagSet(fs, "count"), *verbose, *opts); err != nil {
+			fmt.Printf("Error encoding JSON: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	if !interactive {
+		printResults(passwords, *opts)
+		// Hashes follow the passwords in the same order, one per line, and
+		// are the only stdout output when the passwords went to -out or
+		// -clipboard
+		for _, hash := range hashes {
+			fmt.Println(hash)
+		}
+		// Keep stdout limited to passwords so the output stays scriptable
+		if *verbose {
+			for _, password := range passwords {
+				fmt.Fprintln(os.Stderr, formatBreakdown(password))
+			}
+		}
+		if !opts.quiet {
+			// The warning names characters of the password, which -store
+			// keeps off the screen
+			if !*noAmbiguousWarning && opts.store == "" {
+				warnAmbiguous(passwords)
+			}
+			fmt.Fprintln(os.Stderr, formatEntropy(entropy, *guessRate))
+		}
+		clearEphemeral(*opts)
+		return
+	}
+
+	for {
+		fmt.Println("\nYour generated password is:")
+		fmt.Println("------------------------")
+		for _, password := range passwords {
+			fmt.Println(opts.grouped(password))
+		}
+		fmt.Println("------------------------")
+		if !*noAmbiguousWarning {
+			warnAmbiguous(passwords)
+		}
+		fmt.Println(formatEntropy(entropy, *guessRate))
+
+		// Answers piped in for the prompts above are not a person who can
+		// decide they want another one. Ending the input, e.g. with Ctrl-D,
+		// is as good as answering no
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return
+		}
+		if again, err := readYesNoDefault("\nGenerate another with same settings?", true); err != nil || !again {
+			return
+		}
+		passwords, err = reroll(config, passwords)
+		if err != nil {
+			exitGenerationError(err)
+		}
+	}
+}
+
+// generatorMode returns the flag, without its dash, that selects a
+// generator other than the password generator, or "" when none is selected.
+// The arguments are the values of those flags, in the order generate checks
+// them
+func generatorMode(passphrase, memorable, wordNumberWord, pin, token, pattern, pronounceable bool) string {
+	modes := []struct {
+		name string
+		on   bool
+	}{
+		{"passphrase", passphrase},
+		{"memorable", memorable},
+		{"word-number-word", wordNumberWord},
+		{"pin", pin},
+		{"token", token},
+		{"pattern", pattern},
+		{"pronounceable", pronounceable},
+	}
+	for _, m := range modes {
+		if m.on {
+			return m.name
+		}
+	}
+	return ""
+}
+
+// exitGenerationError reports a password generation error and exits. When
+// the constraints could not be met it also suggests how to loosen them
+func exitGenerationError(err error) {
+	fmt.Printf("Error generating password: %v\n", err)
+	if errors.Is(err, passinator.ErrGenerationExhausted) {
+		fmt.Println("The settings are too strict to satisfy reliably: relax constraints such as -unique, -start-letter, -no-sequences, -blocklist, -match or -check-pwned, increase -length, or raise -max-attempts")
+	}
+	os.Exit(exitError)
+}
+
+// maxRerollAttempts bounds how often reroll regenerates a password that is
+// too similar to the previous one, since a tiny character set may make that
+// unavoidable
+const maxRerollAttempts = 10
+
+// reroll generates a new batch with config, regenerating any password that is
+// identical or too similar to the one it replaces. After maxRerollAttempts
+// the last candidate is used anyway
+func reroll(config passinator.PasswordConfig, previous []string) ([]string, error) {
+	passwords, err := passinator.GeneratePasswords(config)
+	if err != nil {
+		return nil, err
+	}
+	for i := range passwords {
+		if i >= len(previous) {
+			break
+		}
+		// Random passwords share about one position in a character set's
+		// size, so more than a quarter in common is no coincidence
+		maxSame := utf8.RuneCountInString(previous[i]) / 4
+		for attempt := 0; attempt < maxRerollAttempts && tooSimilar(passwords[i], previous[i], maxSame); attempt++ {
+			if passwords[i], err = passinator.GeneratePassword(config); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return passwords, nil
+}
+
+// tooSimilar reports whether a and b are identical or have more than maxSame
+// characters in the same positions
+func tooSimilar(a, b string, maxSame int) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	same := 0
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		if ra[i] == rb[i] {
+			same++
+		}
+	}
+	return same > maxSame
+}
+
+// runPIN generates and prints count PINs of the given length, all distinct
+// when unique is set and found within attempts tries
+func runPIN(length, count int, unique bool, attempts int, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating PIN: PIN count must be at least 1")
+		os.Exit(exitError)
+	}
+	if unique {
+		if err := checkUniqueKeyspace(count, float64(length)*math.Log2(10), "PINs"); err != nil {
+			fmt.Printf("Error generating PIN: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+	pins, err := generateBatch(count, unique, attempts, func() (string, error) {
+		return passinator.GeneratePIN(length)
+	})
+	if err != nil {
+		fmt.Printf("Error generating PIN: %v\n", err)
+		os.Exit(exitError)
+	}
+	printResults(pins, opts)
+	clearEphemeral(opts)
+}
+
+// runPattern generates and prints count passwords following pattern, which
+// may mix placeholders for words drawn from wordlist with character
+// placeholders
+func runPattern(pattern, wordlist string, count int, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating password: password count must be at least 1")
+		os.Exit(exitError)
+	}
+	passwords := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		password, err := passinator.GeneratePassphrasePatternFrom(wordlist, pattern)
+		if err != nil {
+			fmt.Printf("Error generating password: %v\n", err)
+			os.Exit(exitError)
+		}
+		passwords = append(passwords, password)
+	}
+	printResults(passwords, opts)
+	clearEphemeral(opts)
+}
+
+// runPronounceable generates and prints count pronounceable passwords
+// followed by their strength report
+func runPronounceable(config passinator.PronounceableConfig, count int, guessRate float64, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating password: password count must be at least 1")
+		os.Exit(exitError)
+	}
+	passwords := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		password, err := passinator.GenerateCustomPronounceable(config)
+		if err != nil {
+			fmt.Printf("Error generating password: %v\n", err)
+			os.Exit(exitError)
+		}
+		passwords = append(passwords, password)
+	}
+	printResults(passwords, opts)
+	if !opts.quiet {
+		fmt.Fprintln(os.Stderr, formatEntropy(passinator.PronounceableEntropy(config), guessRate))
+	}
+	clearEphemeral(opts)
+}
+
+// deterministicPassword reads the master password from stdin, without echo
+// when it is a terminal, and derives the password for site from it
+func deterministicPassword(site string, config passinator.PasswordConfig) ([]string, error) {
+	master, err := readSecret("Master password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master password: %w", err)
+	}
+	password, err := passinator.GenerateDeterministic(master, site, config)
+	if err != nil {
+		return nil, err
+	}
+	return []string{password}, nil
+}
+
+// generateAnchored generates config.Count passwords embedding anchor at
+// position, all distinct when config.Unique is set
+func generateAnchored(config passinator.PasswordConfig, anchor, position string) ([]string, error) {
+	if config.Count <= 0 {
+		return nil, fmt.Errorf("password count must be at least 1")
+	}
+	if config.Unique {
+		// Only the random part tells the passwords apart
+		bits, err := passinator.AnchoredEntropy(config, anchor, position)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkUniqueKeyspace(config.Count, bits, "passwords"); err != nil {
+			return nil, err
+		}
+	}
+	return generateBatch(config.Count, config.Unique, passinator.BatchAttempts(config, config.Count), func() (string, error) {
+		return passinator.GenerateAnchored(config, anchor, position)
+	})
+}
+
+// warnAnchor warns that anchor adds no strength to passwords, saying how many
+// of their characters do. With a -length range that differs per password, so
+// the fewest and most are given
+func warnAnchor(anchor string, passwords []string) {
+	fewest, most := 0, 0
+	for i, password := range passwords {
+		n := utf8.RuneCountInString(password) - utf8.RuneCountInString(anchor)
+		if i == 0 {
+			fewest, most = n, n
+		}
+		fewest, most = min(fewest, n), max(most, n)
+	}
+	if fewest == most {
+		fmt.Fprintf(os.Stderr, "Warning: the anchor %q is not secret, so only the other %d characters add strength\n", anchor, fewest)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: the anchor %q is not secret, so only the other %d to %d characters add strength\n", anchor, fewest, most)
+	}
+}
+
+// lengthForEntropy returns the shortest length at which a password drawn
+// uniformly from charsetSize characters has at least bits of entropy
+func lengthForEntropy(bits float64, charsetSize int) int {
+	if charsetSize < 2 {
+		return 0
+	}
+	return int(math.Ceil(bits / math.Log2(float64(charsetSize))))
+}
+
+// applyTargetEntropy sets config.Length to the shortest length, never below
+// the minimum length or the per-category minimums, whose estimated entropy
+// reaches bits. The caller validates the result
+func applyTargetEntropy(config *passinator.PasswordConfig, bits float64) error {
+	size := passinator.CharsetSize(*config)
+	if size < 2 {
+		return fmt.Errorf("a character set of %d characters cannot reach %.0f bits", size, bits)
+	}
+	config.Length = max(lengthForEntropy(bits, size), passinator.MinPasswordLength, config.MinLength, passinator.RequiredLength(*config))
+	// Balanced and repeat-free passwords carry less per character than the
+	// uniform estimate, so lengthen them until the estimate agrees
+	for passinator.EstimateEntropy(*config) < bits {
+		if config.NoRepeats && config.Length >= size {
+			return fmt.Errorf("%.0f bits cannot be reached without repeating characters from %d available", bits, size)
+		}
+		config.Length++
+	}
+	return nil
+}
@@ -1,3 +1,11 @@
 module pass-inator
 
 go 1.24.3
+
+require (
+	golang.org/x/crypto v0.48.0
+	golang.org/x/term v0.40.0
+	rsc.io/qr v0.2.0
+)
+
+require golang.org/x/sys v0.41.0 // indirect
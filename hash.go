@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported -hash algorithms
+const (
+	hashBcrypt = "bcrypt"
+	hashArgon2 = "argon2"
+)
+
+// validateHashAlgorithm reports an error if algo is not a supported -hash
+// algorithm
+func validateHashAlgorithm(algo string) error {
+	if algo != hashBcrypt && algo != hashArgon2 {
+		return fmt.Errorf("unknown hash algorithm %q (use %s or %s)", algo, hashBcrypt, hashArgon2)
+	}
+	return nil
+}
+
+// Argon2id parameters for hashPassword, the RFC 9106 second recommended
+// option, which is also the default of most server libraries
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// hashPassword hashes pw with algo for storage on a server. bcrypt hashes use
+// the modular crypt format ("$2a$10$..."), which is limited to 72 bytes of
+// password, and argon2 hashes are Argon2id in the PHC string format
+// ("$argon2id$v=19$m=65536,t=3,p=4$salt$hash") with a random salt
+func hashPassword(pw, algo string) (string, error) {
+	switch algo {
+	case hashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password with bcrypt: %w", err)
+		}
+		return string(hash), nil
+	case hashArgon2:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+		key := argon2.IDKey([]byte(pw), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", validateHashAlgorithm(algo)
+	}
+}
+
+// hashPasswords hashes every password with algo, in order
+func hashPasswords(passwords []string, algo string) ([]string, error) {
+	hashes := make([]string, 0, len(passwords))
+	for _, password := range passwords {
+		hash, err := hashPassword(password, algo)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"pass-inator/passinator"
+)
+
+// jsonlWriter writes passwords as JSON Lines: one passwordOutput object per
+// line, numbered from 1. Every line goes out in a single write as soon as it
+// is encoded, so a consumer reading the other end of a pipe can process each
+// password as it arrives
+type jsonlWriter struct {
+	enc     *json.Encoder
+	entropy float64
+	verbose bool
+	opts    outputOptions
+	index   int
+}
+
+// newJSONLWriter returns a jsonlWriter writing to w, reporting entropy for
+// every password and its breakdown when verbose is set
+func newJSONLWriter(w io.Writer, entropy float64, verbose bool, opts outputOptions) *jsonlWriter {
+	enc := json.NewEncoder(w)
+	// Passwords routinely contain <, > and &, which must not be escaped
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{
+		enc:     enc,
+		entropy: roundEntropy(entropy),
+		verbose: verbose,
+		opts:    opts,
+	}
+}
+
+// write writes the line of the next password, with hash when it is not ""
+func (jw *jsonlWriter) write(password, hash string) error {
+	jw.index++
+	output := passwordOutput{
+		Index:    jw.index,
+		Password: password,
+		Length:   utf8.RuneCountInString(password),
+		Entropy:  jw.entropy,
+		Hash:     hash,
+	}
+	if jw.opts.group > 0 {
+		output.Grouped = jw.opts.grouped(password)
+	}
+	if jw.verbose {
+		output.Breakdown = passinator.AnalyzePassword(password)
+	}
+	return jw.enc.Encode(output)
+}
+
+// withRecordOutput calls write with the destination of JSON, JSON Lines or
+// CSV output: the -out file, created readable only by the owner and synced
+// afterwards, or stdout
+func withRecordOutput(opts outputOptions, write func(io.Writer) error) error {
+	if opts.outPath == "" {
+		return write(os.Stdout)
+	}
+	f, err := openOutputFile(opts.outPath, opts.truncate)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writeErr := write(f)
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// streamJSONL generates config.Count passwords and writes each as a JSON
+// line the moment it is generated, reporting the count to prog. When ctx is
+// cancelled the lines written so far stay and ctx.Err() is returned
+func streamJSONL(ctx context.Context, config passinator.PasswordConfig, entropy float64, verbose bool, opts outputOptions, prog *progress) error {
+	return withRecordOutput(opts, func(w io.Writer) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results, errs := generatePasswordsCtx(ctx, config)
+
+		jw := newJSONLWriter(w, entropy, verbose, opts)
+		for password := range results {
+			if err := jw.write(password, ""); err != nil {
+				// Stop the generator and let it close the channel
+				cancel()
+				for range results {
+				}
+				return err
+			}
+			prog.update(jw.index)
+		}
+		prog.finish(jw.index)
+		return <-errs
+	})
+}
+
+// printJSONL writes passwords that have already been generated as JSON
+// Lines, with hashes[i] as the hash of passwords[i] when hashes is not nil
+func printJSONL(passwords, hashes []string, entropy float64, verbose bool, opts outputOptions) error {
+	return withRecordOutput(opts, func(w io.Writer) error {
+		jw := newJSONLWriter(w, entropy, verbose, opts)
+		for i, password := range passwords {
+			hash := ""
+			if hashes != nil {
+				hash = hashes[i]
+			}
+			if err := jw.write(password, hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
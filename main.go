@@ -1,187 +1,107 @@
 package main
 
 import (
-	"bufio"
-	"crypto/rand"
+	"flag"
 	"fmt"
-	"math/big"
 	"os"
-	"strconv"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 const (
-	minPasswordLength = 6
-	lowercaseChars    = "abcdefghijklmnopqrstuvwxyz"
-	uppercaseChars    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	numberChars       = "0123456789"
-	specialChars      = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	defaultPasswordLength = 16
+	defaultPINLength      = 6
+	defaultMemorableWords = 3
 )
 
-// PasswordConfig holds the configuration for password generation
-type PasswordConfig struct {
-	Length          int
-	UseLowercase    bool
-	UseUppercase    bool
-	UseNumbers      bool
-	UseSpecialChars bool
-}
-
-// secureRandomInt generates a cryptographically secure random integer in [0, max)
-func secureRandomInt(max int) (int, error) {
-	if max <= 0 {
-		return 0, fmt.Errorf("max must be positive")
-	}
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
-	if err != nil {
-		return 0, err
-	}
-	return int(n.Int64()), nil
-}
+// Exit codes. Invalid flags exit with 2, as reported by the flag package
+const (
+	exitError       = 1
+	exitPolicy      = 3
+	exitInterrupted = 130
+)
 
-// secureRandomBytes generates n cryptographically secure random bytes
-func secureRandomBytes(n int) ([]byte, error) {
-	b := make([]byte, n)
-	_, err := rand.Read(b)
-	return b, err
+// commands maps each subcommand name to the function implementing it. Every
+// command parses its own flags from the arguments that follow its name
+var commands = map[string]func(args []string){
+	"generate":   generateCommand,
+	"passphrase": passphraseCommand,
+	"token":      tokenCommand,
+	"check":      checkCommand,
 }
 
-// validateConfig ensures the password configuration is valid
-func validateConfig(config PasswordConfig) error {
-	if config.Length < minPasswordLength {
-		return fmt.Errorf("password length must be at least %d characters", minPasswordLength)
+// commandSummary lists the commands in the order shown by the usage message
+const commandSummary = `Commands:
+  generate    generate passwords (the default when no command is given)
+  passphrase  generate diceware-style passphrases
+  token       generate random tokens for API keys and secrets
+  check       check an existing password against a policy
+`
+
+// rootUsage returns the usage function of the generate command, which also
+// describes the other commands since it runs when no command is given
+func rootUsage(fs *flag.FlagSet) func() {
+	return func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s [command] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(out, "Generates a cryptographically secure password. When no flags are given,")
+		fmt.Fprintln(out, "the options are asked for interactively.")
+		fmt.Fprintf(out, "\n%s", commandSummary)
+		fmt.Fprintf(out, "\nRun '%s <command> -h' for the flags of each command.\n", os.Args[0])
+		fmt.Fprintln(out, "\nGenerate flags:")
+		fs.PrintDefaults()
 	}
-	if !config.UseLowercase && !config.UseUppercase && !config.UseNumbers && !config.UseSpecialChars {
-		return fmt.Errorf("at least one character type must be selected")
-	}
-	return nil
 }
 
-// generatePassword creates a password based on the provided configuration
-func generatePassword(config PasswordConfig) (string, error) {
-	if err := validateConfig(config); err != nil {
-		return "", err
-	}
-
-	// Build character set based on configuration
-	var charSet string
-	if config.UseLowercase {
-		charSet += lowercaseChars
-	}
-	if config.UseUppercase {
-		charSet += uppercaseChars
-	}
-	if config.UseNumbers {
-		charSet += numberChars
-	}
-	if config.UseSpecialChars {
-		charSet += specialChars
-	}
-
-	// Ensure at least one character from each selected type
-	var password strings.Builder
-	if config.UseLowercase {
-		idx, err := secureRandomInt(len(lowercaseChars))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random index: %w", err)
-		}
-		password.WriteByte(lowercaseChars[idx])
-	}
-	if config.UseUppercase {
-		idx, err := secureRandomInt(len(uppercaseChars))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random index: %w", err)
-		}
-		password.WriteByte(uppercaseChars[idx])
-	}
-	if config.UseNumbers {
-		idx, err := secureRandomInt(len(numberChars))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random index: %w", err)
-		}
-		password.WriteByte(numberChars[idx])
-	}
-	if config.UseSpecialChars {
-		idx, err := secureRandomInt(len(specialChars))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random index: %w", err)
-		}
-		password.WriteByte(specialChars[idx])
-	}
-
-	// Fill the rest of the password with random characters
-	remainingLength := config.Length - password.Len()
-	for i := 0; i < remainingLength; i++ {
-		idx, err := secureRandomInt(len(charSet))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random index: %w", err)
-		}
-		password.WriteByte(charSet[idx])
-	}
-
-	// Shuffle the password using Fisher-Yates algorithm with crypto/rand
-	passwordStr := password.String()
-	passwordRunes := []rune(passwordStr)
-	for i := len(passwordRunes) - 1; i > 0; i-- {
-		j, err := secureRandomInt(i + 1)
-		if err != nil {
-			return "", fmt.Errorf("failed to shuffle password: %w", err)
-		}
-		passwordRunes[i], passwordRunes[j] = passwordRunes[j], passwordRunes[i]
+// commandUsage returns a usage function for the command parsed by fs, showing
+// its positional arguments, a description and its flags
+func commandUsage(fs *flag.FlagSet, arguments, description string) func() {
+	return func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s [flags] %s\n\n", os.Args[0], fs.Name(), arguments)
+		fmt.Fprintln(out, description)
+		fmt.Fprintln(out, "\nFlags:")
+		fs.PrintDefaults()
 	}
-
-	return string(passwordRunes), nil
 }
 
-func readUserInput(prompt string) string {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print(prompt)
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+// isFlagSet reports whether the named flag was given on the command line of fs
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
-func readYesNo(prompt string) bool {
-	for {
-		input := strings.ToLower(readUserInput(prompt))
-		if input == "y" || input == "yes" {
-			return true
-		}
-		if input == "n" || input == "no" {
-			return false
-		}
-		fmt.Println("Please enter 'y' or 'n'")
+// prepareOutputOptions exits with an error if opts cannot be honoured, and
+// otherwise turns on quiet mode when stdout is not a terminal, so that the
+// output can be captured with $(...) without stripping decorations
+func prepareOutputOptions(opts *outputOptions) {
+	if err := opts.resolve(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		opts.quiet = true
 	}
 }
 
 func main() {
-	fmt.Println("Welcome to Pass-inator - Your Secure Password Generator")
-	fmt.Println("-----------------------------------------------------")
-
-	// Get password length
-	lengthStr := readUserInput(fmt.Sprintf("Enter password length (minimum %d): ", minPasswordLength))
-	length, err := strconv.Atoi(lengthStr)
-	if err != nil {
-		fmt.Printf("Error: Invalid length. Using minimum length of %d\n", minPasswordLength)
-		length = minPasswordLength
-	}
-
-	config := PasswordConfig{
-		Length:          length,
-		UseLowercase:    readYesNo("Include lowercase letters? (y/n): "),
-		UseUppercase:    readYesNo("Include uppercase letters? (y/n): "),
-		UseNumbers:      readYesNo("Include numbers? (y/n): "),
-		UseSpecialChars: readYesNo("Include special characters? (y/n): "),
+	// Anything that is not a flag selects a command; otherwise the arguments
+	// belong to generate, which keeps the original flag-only invocations working
+	name, args := "generate", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// Generate and display password
-	password, err := generatePassword(config)
-	if err != nil {
-		fmt.Printf("Error generating password: %v\n", err)
-		os.Exit(1)
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", name, commandSummary)
+		os.Exit(2)
 	}
-
-	fmt.Println("\nYour generated password is:")
-	fmt.Println("------------------------")
-	fmt.Println(password)
-	fmt.Println("------------------------")
+	command(args)
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"pass-inator/passinator"
+)
+
+// generateMatching generates passwords with config until one matches re,
+// giving up after the config's AttemptLimit. A pattern that the
+// configuration rarely or never satisfies therefore fails with an error
+// instead of hanging
+func generateMatching(config passinator.PasswordConfig, re *regexp.Regexp) (string, error) {
+	maxTries := passinator.AttemptLimit(config)
+	for i := 0; i < maxTries; i++ {
+		password, err := passinator.GeneratePassword(config)
+		if err != nil {
+			return "", err
+		}
+		if re.MatchString(password) {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("no password matching %s found in %d attempts; check that the pattern fits the length and character types: %w", re, maxTries, passinator.ErrGenerationExhausted)
+}
+
+// generateAllMatching generates config.Count passwords that each match re,
+// all distinct when config.Unique is set
+func generateAllMatching(config passinator.PasswordConfig, re *regexp.Regexp) ([]string, error) {
+	if err := passinator.ValidateBatch(config, config.Count); err != nil {
+		return nil, err
+	}
+	return generateBatch(config.Count, config.Unique, passinator.BatchAttempts(config, config.Count), func() (string, error) {
+		return generateMatching(config, re)
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"pass-inator/passinator"
+)
+
+// menuSettings holds what the menu generates and checks with. It starts from
+// the flags given with -menu and is changed from the settings entry
+type menuSettings struct {
+	password      passinator.PasswordConfig
+	passphrase    passinator.PassphraseConfig
+	tokenBytes    int
+	tokenEncoding string
+	// forChecking adapts the password settings for checking an existing
+	// password, as policyFlags.forChecking does for the check command
+	forChecking func(passinator.PasswordConfig) passinator.PasswordConfig
+	// noAmbiguousWarning is set by -no-ambiguous-warning
+	noAmbiguousWarning bool
+}
+
+// menuAction is an entry of the menu, chosen by typing its key
+type menuAction struct {
+	key   string
+	label string
+	run   func(s *menuSettings, guessRate float64, opts outputOptions) error
+}
+
+// menuActions lists the menu entries in the order they are shown
+var menuActions = []menuAction{
+	{"1", "Generate a password", menuGeneratePassword},
+	{"2", "Generate a passphrase", menuGeneratePassphrase},
+	{"3", "Generate a token", menuGenerateToken},
+	{"4", "Check a password", menuCheckPassword},
+	{"5", "Settings", menuChangeSettings},
+}
+
+// runMenu shows a numbered menu of the generators and runs the chosen entry
+// until the user quits or stdin ends. An entry that fails reports its error
+// and returns to the menu instead of exiting
+func runMenu(settings menuSettings, guessRate float64, opts outputOptions) {
+	fmt.Println("Welcome to Pass-inator - Your Secure Password Generator")
+	fmt.Println("-----------------------------------------------------")
+	for {
+		fmt.Println()
+		for _, action := range menuActions {
+			fmt.Printf("  %s) %s\n", action.key, action.label)
+		}
+		fmt.Println("  q) Quit")
+
+		choice, err := readUserInput("Choose an option: ")
+		if err != nil {
+			exitMenu(err)
+		}
+		choice = strings.ToLower(choice)
+		if choice == "q" || choice == "quit" {
+			return
+		}
+		var run func(*menuSettings, float64, outputOptions) error
+		for _, action := range menuActions {
+			if action.key == choice {
+				run = action.run
+			}
+		}
+		if run == nil {
+			fmt.Printf("Please enter 1-%d, or q to quit\n", len(menuActions))
+			continue
+		}
+		fmt.Println()
+		if err := run(&settings, guessRate, opts); err != nil {
+			if errors.Is(err, errInputClosed) {
+				exitMenu(err)
+			}
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// exitMenu leaves the menu after a read error. The end of stdin, such as
+// Ctrl-D, is as good as choosing to quit
+func exitMenu(err error) {
+	if errors.Is(err, errInputClosed) {
+		os.Exit(0)
+	}
+	fmt.Printf("Error reading input: %v\n", err)
+	os.Exit(exitError)
+}
+
+// menuGeneratePassword generates passwords with the current password
+// settings, as generate does
+func menuGeneratePassword(s *menuSettings, guessRate float64, opts outputOptions) error {
+	passwords, err := passinator.GeneratePasswords(s.password)
+	if err != nil {
+		return err
+	}
+	printResults(passwords, opts)
+	// As in generate, the warning is not shown for results -store keeps off
+	// the screen
+	if !s.noAmbiguousWarning && opts.store == "" {
+		warnAmbiguous(passwords)
+	}
+	fmt.Println(formatEntropy(passinator.EstimateEntropy(s.password), guessRate))
+	clearEphemeral(opts)
+	return nil
+}
+
+// menuGeneratePassphrase generates a passphrase with the current passphrase
+// settings, as the passphrase command does
+func menuGeneratePassphrase(s *menuSettings, guessRate float64, opts outputOptions) error {
+	entropy, err := passinator.PassphraseEntropy(s.passphrase)
+	if err != nil {
+		return err
+	}
+	passphrases, err := generatePassphrases(s.passphrase, 1)
+	if err != nil {
+		return err
+	}
+	printResults(passphrases, opts)
+	fmt.Println(formatEntropy(entropy, guessRate))
+	clearEphemeral(opts)
+	return nil
+}
+
+// menuGenerateToken generates a token with the current token settings, as
+// the token command does
+func menuGenerateToken(s *menuSettings, guessRate float64, opts outputOptions) error {
+	tokens, err := generateTokens(s.tokenBytes, s.tokenEncoding, 1)
+	if err != nil {
+		return err
+	}
+	printResults(tokens, opts)
+	clearEphemeral(opts)
+	return nil
+}
+
+// menuCheckPassword reads an existing password without echo and checks it
+// against the current password settings, as the check command does
+func menuCheckPassword(s *menuSettings, guessRate float64, opts outputOptions) error {
+	password, err := readSecret("Password: ")
+	if err != nil {
+		return err
+	}
+	printPolicyReport(password, passinator.CheckPolicy(password, s.forChecking(s.password)))
+	return nil
+}
+
+// menuChangeSettings asks the promptConfig questions for the password
+// settings, then for the passphrase words and token size, offering the
+// current values as defaults. Settings the questions do not cover, such as
+// exclusions given as flags, are kept
+func menuChangeSettings(s *menuSettings, guessRate float64, opts outputOptions) error {
+	var err error
+	if s.password, err = promptPasswordSettings(s.password); err != nil {
+		return err
+	}
+	if err := passinator.ValidateConfig(s.password); err != nil {
+		// Still saved, so the user can see the problem and fix it
+		fmt.Printf("Warning: passwords cannot be generated with these settings: %v\n", err)
+	}
+
+	if s.passphrase.Words, err = readInt(fmt.Sprintf("Words per passphrase [%d]: ", s.passphrase.Words), 1, s.passphrase.Words); err != nil {
+		return err
+	}
+	if s.tokenBytes, err = readInt(fmt.Sprintf("Random bytes per token [%d]: ", s.tokenBytes), 1, s.tokenBytes); err != nil {
+		return err
+	}
+	fmt.Println("Settings saved")
+	return nil
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"pass-inator/passinator"
+)
+
+// formatEntropy renders an entropy value along with its strength label and
+// the estimated time to crack it at guessRate guesses per second
+func formatEntropy(bits, guessRate float64) string {
+	return fmt.Sprintf("Entropy: %.1f bits (%s)\nEstimated time to crack: %s at %.0e guesses/sec",
+		bits, strengthLabel(bits), passinator.CrackTimeEstimate(bits, guessRate), guessRate)
+}
+
+// roundEntropy rounds bits to one decimal place, so that machine-readable
+// output reports the same entropy as formatEntropy
+func roundEntropy(bits float64) float64 {
+	return math.Round(bits*10) / 10
+}
+
+// strengthLabel returns the passinator.StrengthLabel of bits, colored for the
+// terminal when colors are enabled
+func strengthLabel(bits float64) string {
+	label := passinator.StrengthLabel(bits)
+	return colorize(label, label)
+}
+
+// formatBreakdown renders the per-category character counts of password
+func formatBreakdown(password string) string {
+	counts := passinator.AnalyzePassword(password)
+	parts := make([]string, 0, len(passinator.Categories))
+	for _, category := range passinator.Categories {
+		parts = append(parts, fmt.Sprintf("%s: %d", category, counts[category]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ambiguousWarning describes the easily confused characters of
+// passinator.AmbiguousChars found in password, such as "contains 2 ambiguous
+// characters (O, l)", or returns "" when there are none
+func ambiguousWarning(password string) string {
+	var found []string
+	count := 0
+	for _, r := range password {
+		if !strings.ContainsRune(passinator.AmbiguousChars, r) {
+			continue
+		}
+		count++
+		if !slices.Contains(found, string(r)) {
+			found = append(found, string(r))
+		}
+	}
+	switch count {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("contains 1 ambiguous character (%s)", found[0])
+	default:
+		return fmt.Sprintf("contains %d ambiguous characters (%s)", count, strings.Join(found, ", "))
+	}
+}
+
+// warnAmbiguous prints an ambiguousWarning for each password to stderr,
+// numbering them when there are several
+func warnAmbiguous(passwords []string) {
+	for i, password := range passwords {
+		warning := ambiguousWarning(password)
+		if warning == "" {
+			continue
+		}
+		if len(passwords) > 1 {
+			warning = fmt.Sprintf("password %d %s", i+1, warning)
+		}
+		fmt.Fprintln(os.Stderr, "⚠ "+warning)
+	}
+}
+
+// passwordOutput is the JSON representation of a generated password. Index
+// numbers the lines of -jsonl output from 1 and is left out of -json
+type passwordOutput struct {
+	Index     int            `json:"index,omitempty"`
+	Password  string         `json:"password"`
+	Length    int            `json:"length"`
+	Entropy   float64        `json:"entropy"`
+	Grouped   string         `json:"grouped,omitempty"`
+	Hash      string         `json:"hash,omitempty"`
+	Breakdown map[string]int `json:"breakdown,omitempty"`
+}
+
+// printJSON writes passwords as JSON to stdout or the -out file, using an
+// array when asArray is set and a single object otherwise. The per-category breakdown is included
+// when verbose is set, the grouped form when opts asks for grouping, and
+// hashes[i] as the hash of passwords[i] when hashes is not nil
+func printJSON(passwords, hashes []string, entropy float64, asArray, verbose bool, opts outputOptions) error {
+	entropy = roundEntropy(entropy)
+
+	outputs := make([]passwordOutput, 0, len(passwords))
+	for i, password := range passwords {
+		output := passwordOutput{
+			Password: password,
+			Length:   utf8.RuneCountInString(password),
+			Entropy:  entropy,
+		}
+		if opts.group > 0 {
+			output.Grouped = opts.grouped(password)
+		}
+		if hashes != nil {
+			output.Hash = hashes[i]
+		}
+		if verbose {
+			output.Breakdown = passinator.AnalyzePassword(password)
+		}
+		outputs = append(outputs, output)
+	}
+
+	var v any = outputs
+	if !asArray && len(outputs) == 1 {
+		v = outputs[0]
+	}
+	return withRecordOutput(opts, func(w io.Writer) error {
+		// Passwords routinely contain <, > and &, which must not be escaped
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	})
+}
+
+// entropyOutput is the JSON representation of an entropy estimate
+type entropyOutput struct {
+	Entropy   float64 `json:"entropy"`
+	Strength  string  `json:"strength"`
+	CrackTime string  `json:"crack_time"`
+}
+
+// printEntropy writes the entropy report for bits to stdout, as JSON when
+// asJSON is set
+func printEntropy(bits, guessRate float64, asJSON bool) error {
+	if !asJSON {
+		fmt.Println(formatEntropy(bits, guessRate))
+		return nil
+	}
+	enc := json.NewEncoder(os.Stdout)
+	return enc.Encode(entropyOutput{
+		Entropy:   roundEntropy(bits),
+		Strength:  passinator.StrengthLabel(bits),
+		CrackTime: passinator.CrackTimeEstimate(bits, guessRate),
+	})
+}
+
+// outputOptions controls where generated values are sent
+type outputOptions struct {
+	clipboard bool
+	outPath   string
+	truncate  bool
+	envName   string
+	qr        bool
+	show      bool
+	quiet     bool
+	batchSep  string
+	group     int
+	groupSep  string
+	phonetic  bool
+	ephemeral int
+	store     string
+}
+
+// addOutputFlags registers the flags that fill in an outputOptions on fs
+func addOutputFlags(fs *flag.FlagSet) *outputOptions {
+	opts := &outputOptions{}
+	fs.BoolVar(&opts.clipboard, "clipboard", false, "copy the result to the system clipboard instead of printing it")
+	fs.StringVar(&opts.outPath, "out", "", "append the results to `file` (created with 0600 permissions) instead of printing them")
+	fs.BoolVar(&opts.truncate, "truncate", false, "with -out, replace the file contents instead of appending")
+	fs.StringVar(&opts.envName, "env", "", "print the result as a shell `export VARNAME='...'` statement (numbered with -count)")
+	fs.BoolVar(&opts.qr, "qr", false, "render the result as a QR code instead of printing it (requires building with -tags qr)")
+	fs.BoolVar(&opts.show, "show", false, "with -qr, also print the result in plain text")
+	fs.StringVar(&opts.batchSep, "batch-sep", "", "separator between the values of a batch instead of a newline, such as , or \\0 for xargs -0 (escapes: \\n \\t \\r \\0 \\\\)")
+	fs.IntVar(&opts.group, "group", 0, "display the result in groups of `N` characters (files, the clipboard and -env get it ungrouped)")
+	fs.StringVar(&opts.groupSep, "group-sep", "-", "with -group, separator placed between groups")
+	fs.BoolVar(&opts.phonetic, "phonetic", false, "after the result, spell it out for reading aloud: NATO code words (upper case for capitals), digit and symbol names")
+	fs.IntVar(&opts.ephemeral, "ephemeral", 0, "on a terminal, clear the screen and scrollback this many `seconds` after showing the result (Ctrl-C clears at once)")
+	fs.StringVar(&opts.store, "store", "", "save the result in the OS credential store (macOS Keychain or Linux Secret Service) under `service/account` instead of printing it")
+	fs.BoolVar(&opts.quiet, "quiet", false, "print only the results, without prompts, confirmations or the strength report (the default when stdout is not a terminal)")
+	return opts
+}
+
+// resolve checks the options that can be rejected before anything is
+// generated and interprets the escape sequences in the batch separator
+func (opts *outputOptions) resolve() error {
+	if opts.envName != "" && !envNamePattern.MatchString(opts.envName) {
+		return fmt.Errorf("%q is not a valid environment variable name", opts.envName)
+	}
+	if opts.group < 0 {
+		return fmt.Errorf("group size must not be negative")
+	}
+	if opts.ephemeral < 0 {
+		return fmt.Errorf("-ephemeral must not be negative")
+	}
+	if opts.store != "" {
+		// Each of these would put the plaintext somewhere else too
+		if opts.clipboard || opts.outPath != "" || opts.qr || opts.envName != "" || opts.phonetic {
+			return fmt.Errorf("-store cannot be combined with -clipboard, -out, -qr, -env or -phonetic")
+		}
+		if _, _, err := parseStoreTarget(opts.store); err != nil {
+			return err
+		}
+	}
+	if opts.batchSep == "" {
+		opts.batchSep = "\n"
+		return nil
+	}
+	sep, err := unescapeSeparator(opts.batchSep)
+	if err != nil {
+		return err
+	}
+	opts.batchSep = sep
+	return nil
+}
+
+// unescapeSeparator interprets the escape sequences \n, \t, \r, \0 and \\ in
+// s, so that separators which are awkward to type in a shell can be given.
+// Any other character is taken literally
+func unescapeSeparator(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("separator %q ends with an unfinished escape sequence", s)
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			return "", fmt.Errorf("unknown escape sequence \\%c in separator", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// joinBatch joins results with opts.batchSep for printing. A newline is
+// added at the end so the shell prompt starts on its own line, except with a
+// NUL separator, which instead terminates every value as xargs -0 expects
+func (opts outputOptions) joinBatch(results []string) string {
+	if opts.batchSep == "\x00" {
+		return strings.Join(results, opts.batchSep) + opts.batchSep
+	}
+	return strings.Join(results, opts.batchSep) + "\n"
+}
+
+// grouped returns s with opts.groupSep inserted after every opts.group
+// characters, or s unchanged when grouping is off. A final group shorter
+// than the others is kept as is
+func (opts outputOptions) grouped(s string) string {
+	if opts.group <= 0 {
+		return s
+	}
+	runes := []rune(s)
+	var b strings.Builder
+	for i := 0; i < len(runes); i += opts.group {
+		if i > 0 {
+			b.WriteString(opts.groupSep)
+		}
+		b.WriteString(string(runes[i:min(i+opts.group, len(runes))]))
+	}
+	return b.String()
+}
+
+// printResults prints each generated value on its own line, or sends them to
+// the file, clipboard or credential store selected in opts instead. Values
+// are formatted as shell export statements first when opts.envName is set,
+// and spelled out afterwards when opts.phonetic is set. Callers call
+// clearEphemeral once they have written everything else
+func printResults(results []string, opts outputOptions) {
+	if opts.store != "" {
+		storeResult(results, opts)
+		return
+	}
+	if opts.phonetic {
+		// Whichever way the results were delivered
+		defer printPhonetic(results)
+	}
+	if opts.envName != "" {
+		results = envExports(opts.envName, results)
+		// The exported value is meant to be used, so it stays ungrouped
+		opts.group = 0
+	}
+
+	if opts.qr {
+		// A QR code stands in for the plaintext unless -show is also given
+		for _, result := range results {
+			code, err := renderQR(result)
+			if err != nil {
+				fmt.Printf("Error rendering QR code: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Print(code)
+			if opts.show {
+				fmt.Println(result)
+			}
+		}
+		return
+	}
+
+	if opts.outPath == "" && !opts.clipboard {
+		if opts.envName != "" {
+			// Export statements only work one per line
+			opts.batchSep = "\n"
+		}
+		grouped := make([]string, 0, len(results))
+		for _, result := range results {
+			grouped = append(grouped, opts.grouped(result))
+		}
+		fmt.Print(opts.joinBatch(grouped))
+		return
+	}
+
+	if opts.outPath != "" {
+		if err := writeToFile(opts.outPath, results, opts.truncate); err != nil {
+			fmt.Printf("Error writing to file: %v\n", err)
+			os.Exit(exitError)
+		}
+		switch {
+		case opts.quiet:
+		case len(results) == 1:
+			fmt.Printf("Wrote 1 password to %s\n", opts.outPath)
+		default:
+			fmt.Printf("Wrote %d passwords to %s\n", len(results), opts.outPath)
+		}
+	}
+
+	if opts.clipboard {
+		if err := copyToClipboard(strings.Join(results, opts.batchSep)); err != nil {
+			fmt.Printf("Error copying to clipboard: %v\n", err)
+			os.Exit(exitError)
+		}
+		switch {
+		case opts.quiet:
+		case len(results) == 1:
+			fmt.Println("Password copied to clipboard")
+		default:
+			fmt.Printf("%d passwords copied to clipboard\n", len(results))
+		}
+	}
+}
+
+// storeResult saves the single value in results in the credential store
+// named by opts.store, printing only a confirmation
+func storeResult(results []string, opts outputOptions) {
+	if len(results) != 1 {
+		fmt.Printf("Error: -store saves a single value, but %d were generated\n", len(results))
+		os.Exit(exitError)
+	}
+	store, err := storeSecret(opts.store, results[0])
+	if err != nil {
+		fmt.Printf("Error storing password: %v\n", err)
+		os.Exit(exitError)
+	}
+	if !opts.quiet {
+		fmt.Printf("Password stored in %s as %s\n", store, opts.store)
+	}
+}
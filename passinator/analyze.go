@@ -0,0 +1,250 @@
+package passinator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Category names used as keys by AnalyzePassword, in display order
+const (
+	CategoryLowercase = "lowercase"
+	CategoryUppercase = "uppercase"
+	CategoryDigits    = "digits"
+	CategorySpecial   = "special"
+)
+
+// CategoryUnicode names the UseUnicode character type in CategoryWeights.
+// AnalyzePassword counts such characters by their class instead
+const CategoryUnicode = "unicode"
+
+// Categories lists the AnalyzePassword category names in display order
+var Categories = []string{CategoryLowercase, CategoryUppercase, CategoryDigits, CategorySpecial}
+
+// AnalyzePassword counts the characters of s in each category. Every
+// character that is not a letter or digit counts as special
+func AnalyzePassword(s string) map[string]int {
+	counts := map[string]int{
+		CategoryLowercase: 0,
+		CategoryUppercase: 0,
+		CategoryDigits:    0,
+		CategorySpecial:   0,
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			counts[CategoryLowercase]++
+		case unicode.IsUpper(r):
+			counts[CategoryUppercase]++
+		case unicode.IsDigit(r):
+			counts[CategoryDigits]++
+		default:
+			counts[CategorySpecial]++
+		}
+	}
+	return counts
+}
+
+// CheckPassword reports every way password fails to satisfy policy, or nil
+// when it complies. The policy is read as a generation config would be:
+// Length is the minimum length and MaxLength, when set, the maximum, each
+// selected character type must be present at least once (or its Min*
+// count), and the exclusions, Blocklist, custom
+// character set, MaxConsecutive, NoRepeats, MustStartWithLetter,
+// NoLeadingSpecial, NoTrailingSpecial, InteriorRequired and AvoidSequences
+// settings must all hold
+func CheckPassword(password string, policy PasswordConfig) []string {
+	var failures []string
+	runes := []rune(password)
+
+	if len(runes) < policy.Length {
+		failures = append(failures, fmt.Sprintf("needs at least %d characters, found %d", policy.Length, len(runes)))
+	}
+	if policy.MaxLength > 0 && len(runes) > policy.MaxLength {
+		failures = append(failures, fmt.Sprintf("needs at most %d characters, found %d", policy.MaxLength, len(runes)))
+	}
+
+	if policy.CustomCharset == "" {
+		counts := AnalyzePassword(password)
+		required := []struct {
+			enabled  bool
+			min      int
+			category string
+		}{
+			{policy.UseLowercase, policy.MinLowercase, CategoryLowercase},
+			{policy.UseUppercase, policy.MinUppercase, CategoryUppercase},
+			{policy.UseNumbers, policy.MinNumbers, CategoryDigits},
+			{policy.UseSpecialChars, policy.MinSpecial, CategorySpecial},
+		}
+		for _, r := range required {
+			if !r.enabled {
+				continue
+			}
+			if need := max(1, r.min); counts[r.category] < need {
+				failures = append(failures, fmt.Sprintf("needs >=%d %s, found %d", need, r.category, counts[r.category]))
+			}
+			interior := r.category == CategoryUppercase || r.category == CategorySpecial
+			if policy.InteriorRequired && interior && counts[r.category] > 0 &&
+				(len(runes) < 3 || AnalyzePassword(string(runes[1 : len(runes)-1]))[r.category] == 0) {
+				failures = append(failures, fmt.Sprintf("has %s only at the first or last position", r.category))
+			}
+		}
+	} else if outside := removeChars(password, policy.CustomCharset); outside != "" {
+		failures = append(failures, fmt.Sprintf("contains characters outside the allowed set: %q", uniqueChars(outside)))
+	}
+
+	excluded := policy.ExcludeChars + groupChars(policy.ExcludeGroups)
+	if policy.ExcludeAmbiguous {
+		excluded += AmbiguousChars
+	}
+	var found []rune
+	for _, r := range uniqueChars(password) {
+		if strings.ContainsRune(excluded, r) {
+			found = append(found, r)
+		}
+	}
+	if len(found) > 0 {
+		failures = append(failures, fmt.Sprintf("contains excluded characters: %q", string(found)))
+	}
+
+	if entry := blockedEntry(password, policy.Blocklist); entry != "" {
+		failures = append(failures, fmt.Sprintf("contains the blocklisted word %q", entry))
+	}
+
+	if policy.MaxConsecutive > 0 {
+		if run := longestRun(runes); run > policy.MaxConsecutive {
+			failures = append(failures, fmt.Sprintf("repeats a character %d times in a row, at most %d allowed", run, policy.MaxConsecutive))
+		}
+	}
+	if policy.NoRepeats && utf8.RuneCountInString(uniqueChars(password)) != len(runes) {
+		failures = append(failures, "contains repeated characters")
+	}
+	if policy.AvoidSequences && hasSequentialRun(password, sequenceLength) {
+		failures = append(failures, "contains a sequential run such as abc, 321 or qwe")
+	}
+	if policy.MustStartWithLetter && (len(runes) == 0 || !unicode.IsLetter(runes[0])) {
+		failures = append(failures, "does not start with a letter")
+	}
+	if policy.NoLeadingSpecial && len(runes) > 0 && !isAlphanumeric(runes[0]) {
+		failures = append(failures, "starts with a special character")
+	}
+	if policy.NoTrailingSpecial && len(runes) > 0 && !isAlphanumeric(runes[len(runes)-1]) {
+		failures = append(failures, "ends with a special character")
+	}
+
+	return failures
+}
+
+// PolicyResult is the outcome of checking a password against a policy, in a
+// form a front-end can display rule by rule
+type PolicyResult struct {
+	// OK is set when the password satisfies every rule
+	OK bool `json:"ok"`
+	// Failures describes each broken rule, such as "needs >=2 digits, found
+	// 1", and is empty but not nil when OK is set
+	Failures []string `json:"failures"`
+	// Entropy is the StrengthOf score of the password
+	Entropy float64 `json:"entropy"`
+	// Breakdown holds the AnalyzePassword counts of the password
+	Breakdown map[string]int `json:"breakdown"`
+}
+
+// CheckPolicy checks password against policy like CheckPassword and returns
+// the failures along with the password's StrengthOf score and character
+// breakdown
+func CheckPolicy(password string, policy PasswordConfig) PolicyResult {
+	failures := CheckPassword(password, policy)
+	if failures == nil {
+		failures = []string{}
+	}
+	return PolicyResult{
+		OK:        len(failures) == 0,
+		Failures:  failures,
+		Entropy:   strength(password),
+		Breakdown: AnalyzePassword(password),
+	}
+}
+
+// longestRun returns the length of the longest run of identical characters
+func longestRun(runes []rune) int {
+	longest, run := 0, 0
+	for i := range runes {
+		if i > 0 && runes[i] == runes[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// ObservedEntropy estimates the entropy of an existing password from the
+// character types it actually contains, as length * log2(combined size of
+// those types). Characters outside the built-in sets are counted using
+// UnicodeChars
+func ObservedEntropy(password string) float64 {
+	pool := observedPool(password)
+	if pool == 0 {
+		return 0
+	}
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
+
+// observedPool returns the combined size of the character types password
+// contains, as counted by ObservedEntropy
+func observedPool(password string) int {
+	counts := AnalyzePassword(password)
+	pool := 0
+	if counts[CategoryLowercase] > 0 {
+		pool += len(LowercaseChars)
+	}
+	if counts[CategoryUppercase] > 0 {
+		pool += len(UppercaseChars)
+	}
+	if counts[CategoryDigits] > 0 {
+		pool += len(NumberChars)
+	}
+	if counts[CategorySpecial] > 0 {
+		pool += len(SpecialChars)
+	}
+	if strings.ContainsFunc(password, func(r rune) bool { return r > unicode.MaxASCII }) {
+		pool += utf8.RuneCountInString(UnicodeChars)
+	}
+	return pool
+}
+
+// StrengthOf estimates the strength of any password, such as one chosen by
+// a user, from the character types it contains, and returns the bits with
+// their StrengthLabel. Unlike ObservedEntropy, which assumes every character
+// was drawn at random, it discounts what an attacker tries first: a
+// character already used counts only log2 of the distinct characters used
+// so far, so "aaaaaaaa" scores almost nothing, and one that completes a
+// sequence such as "abc", "321" or "qwe" counts 1 bit
+func StrengthOf(password string) (float64, string) {
+	runes := []rune(password)
+	lower := []rune(strings.Map(unicode.ToLower, password))
+	perChar := math.Log2(float64(max(1, observedPool(password))))
+	used := make(map[rune]bool)
+	bits := 0.0
+	for i, r := range runes {
+		switch {
+		case i >= sequenceLength-1 && isSequence(string(lower[i-sequenceLength+1:i+1])):
+			bits++
+		case used[r]:
+			bits += math.Log2(float64(len(used)))
+		default:
+			bits += perChar
+		}
+		used[r] = true
+	}
+	return bits, StrengthLabel(bits)
+}
+
+// strength returns the bits of StrengthOf without the label
+func strength(password string) float64 {
+	bits, _ := StrengthOf(password)
+	return bits
+}
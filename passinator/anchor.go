@@ -0,0 +1,149 @@
+package passinator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Positions accepted by GenerateAnchored for the anchor word
+const (
+	AnchorStart  = "start"
+	AnchorEnd    = "end"
+	AnchorRandom = "random"
+)
+
+// AnchorPositions lists the anchor positions in the order they are documented
+var AnchorPositions = []string{AnchorStart, AnchorEnd, AnchorRandom}
+
+// anchorPlan describes where an anchor may go in a password of config.Length
+// characters: between positions lo and hi of the random characters, of which
+// there are random.Length
+type anchorPlan struct {
+	random PasswordConfig
+	lo, hi int
+}
+
+// planAnchor checks that anchor can be embedded at position in a password
+// generated with config, and works out the settings of the random part and
+// the range of positions the anchor may take. The anchor's own first and
+// last characters must satisfy MustStartWithLetter, NoLeadingSpecial and
+// NoTrailingSpecial wherever they would end up at an end of the password
+func planAnchor(config PasswordConfig, anchor, position string) (anchorPlan, error) {
+	if anchor == "" {
+		return anchorPlan{}, fmt.Errorf("anchor must not be empty")
+	}
+	if !slices.Contains(AnchorPositions, position) {
+		return anchorPlan{}, fmt.Errorf("unknown anchor position %q (use %s)", position, strings.Join(AnchorPositions, ", "))
+	}
+	if config.Length < minLength(config) {
+		return anchorPlan{}, fmt.Errorf("%w: length must be at least %d characters", ErrTooShort, minLength(config))
+	}
+	if containsBlocked(anchor, config.Blocklist) {
+		return anchorPlan{}, fmt.Errorf("anchor %q contains a blocklisted word", anchor)
+	}
+	anchorLen := utf8.RuneCountInString(anchor)
+	plan := anchorPlan{random: config}
+	plan.random.Length = config.Length - anchorLen
+	if config.MaxLength > 0 {
+		plan.random.MaxLength = config.MaxLength - anchorLen
+	}
+	if plan.random.Length < 1 {
+		return anchorPlan{}, fmt.Errorf("%w: anchor %q leaves no room for random characters in a %d-character password", ErrTooShort, anchor, config.Length)
+	}
+	// The minimum applies to the whole password, checked above
+	plan.random.MinLength = 1
+	if err := ValidateConfig(plan.random); err != nil {
+		return anchorPlan{}, fmt.Errorf("%d random characters besides the anchor: %w", plan.random.Length, err)
+	}
+
+	first, _ := utf8.DecodeRuneInString(anchor)
+	last, _ := utf8.DecodeLastRuneInString(anchor)
+	canStart := (!config.MustStartWithLetter || unicode.IsLetter(first)) &&
+		(!config.NoLeadingSpecial || isAlphanumeric(first))
+	canEnd := !config.NoTrailingSpecial || isAlphanumeric(last)
+
+	plan.lo, plan.hi = 0, plan.random.Length
+	switch position {
+	case AnchorStart:
+		plan.hi = 0
+	case AnchorEnd:
+		plan.lo = plan.hi
+	}
+	if !canStart && plan.lo == 0 {
+		plan.lo = 1
+	}
+	if !canEnd && plan.hi == plan.random.Length {
+		plan.hi--
+	}
+	if plan.lo > plan.hi {
+		return anchorPlan{}, fmt.Errorf("anchor %q cannot be placed at the %s of the password without breaking its first or last character rules", anchor, position)
+	}
+	return plan, nil
+}
+
+// GenerateAnchored creates a password of config.Length characters that
+// contains anchor, a fixed word chosen by the user such as a pet's name, at
+// the start, at the end or at a random position. The remaining characters
+// are random and satisfy config on their own: the category minimums,
+// exclusions and repeat rules apply to them and not to the anchor, while the
+// Blocklist is checked on the whole password. The anchor must be assumed
+// known to an attacker, so it makes the password weaker than one of the same
+// length that is random throughout; see AnchoredEntropy. With MaxLength,
+// the length is drawn first so that the anchor's position is relative to it
+func GenerateAnchored(config PasswordConfig, anchor, position string) (string, error) {
+	plan, err := planAnchor(config, anchor, position)
+	if err != nil {
+		return "", err
+	}
+	if config.MaxLength > config.Length {
+		n, err := secureRandomInt(config.MaxLength - config.Length + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random length: %w", err)
+		}
+		config.Length, config.MaxLength = config.Length+n, 0
+		if plan, err = planAnchor(config, anchor, position); err != nil {
+			return "", err
+		}
+	}
+	anchorRunes := []rune(anchor)
+	for attempt := 1; ; attempt++ {
+		random, err := GeneratePassword(plan.random)
+		if err != nil {
+			return "", err
+		}
+		pos := plan.lo
+		if plan.hi > plan.lo {
+			n, err := secureRandomInt(plan.hi - plan.lo + 1)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate random position: %w", err)
+			}
+			pos += n
+		}
+		runes := slices.Insert([]rune(random), pos, anchorRunes...)
+		password := string(runes)
+		wipeRunes(runes)
+		// The anchor and the random characters may form a banned word
+		// together
+		if !containsBlocked(password, config.Blocklist) {
+			return password, nil
+		}
+		if attempt >= AttemptLimit(config) {
+			return "", fmt.Errorf("no anchored password without a blocklisted word in %d attempts: %w", attempt, ErrGenerationExhausted)
+		}
+	}
+}
+
+// AnchoredEntropy returns the bits of entropy of a GenerateAnchored password:
+// the EstimateEntropy of the random characters alone, plus the choice of
+// position with AnchorRandom. The anchor itself counts for nothing
+func AnchoredEntropy(config PasswordConfig, anchor, position string) (float64, error) {
+	plan, err := planAnchor(config, anchor, position)
+	if err != nil {
+		return 0, err
+	}
+	return EstimateEntropy(plan.random) + math.Log2(float64(plan.hi-plan.lo+1)), nil
+}
@@ -0,0 +1,38 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// containsBlocked reports whether pw contains any entry of list as a
+// substring, ignoring case
+func containsBlocked(pw string, list []string) bool {
+	return blockedEntry(pw, list) != ""
+}
+
+// blockedEntry returns the first entry of list that pw contains, ignoring
+// case, or "" when it contains none
+func blockedEntry(pw string, list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	lower := strings.ToLower(pw)
+	for _, entry := range list {
+		if strings.Contains(lower, strings.ToLower(entry)) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// validateBlocklist rejects empty Blocklist entries, which every password
+// would contain
+func validateBlocklist(list []string) error {
+	for _, entry := range list {
+		if entry == "" {
+			return fmt.Errorf("blocklist entries must not be empty")
+		}
+	}
+	return nil
+}
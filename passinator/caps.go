@@ -0,0 +1,94 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Capitalization schemes for PassphraseConfig.Caps
+const (
+	CapsNone   = "none"
+	CapsFirst  = "first"
+	CapsAll    = "all"
+	CapsRandom = "random"
+)
+
+// CapsSchemes lists the capitalization schemes in documentation order
+var CapsSchemes = []string{CapsNone, CapsFirst, CapsAll, CapsRandom}
+
+// capsScheme returns the capitalization scheme config asks for, treating
+// Capitalize as CapsFirst
+func capsScheme(config PassphraseConfig) (string, error) {
+	switch {
+	case config.Caps == "" && config.Capitalize:
+		return CapsFirst, nil
+	case config.Caps == "":
+		return CapsNone, nil
+	case config.Capitalize && config.Caps != CapsFirst:
+		return "", fmt.Errorf("the %q capitalization scheme cannot be combined with Capitalize", config.Caps)
+	}
+	for _, scheme := range CapsSchemes {
+		if config.Caps == scheme {
+			return scheme, nil
+		}
+	}
+	return "", fmt.Errorf("unknown capitalization scheme %q (use %s)", config.Caps, strings.Join(CapsSchemes, ", "))
+}
+
+// applyCaps capitalizes words in place according to scheme and returns them.
+// CapsRandom upper-cases every letter with probability 1/2, drawn with
+// secureRandomInt
+func applyCaps(words []string, scheme string) ([]string, error) {
+	for i, word := range words {
+		switch scheme {
+		case CapsNone:
+			words[i] = strings.ToLower(word)
+		case CapsFirst:
+			words[i] = capitalize(word)
+		case CapsAll:
+			words[i] = strings.ToUpper(word)
+		case CapsRandom:
+			var b strings.Builder
+			for _, r := range word {
+				if isCased(r) {
+					upper, err := secureRandomInt(2)
+					if err != nil {
+						return nil, fmt.Errorf("failed to generate random capitalization: %w", err)
+					}
+					if upper == 1 {
+						r = unicode.ToUpper(r)
+					}
+				}
+				b.WriteRune(r)
+			}
+			words[i] = b.String()
+		default:
+			return nil, fmt.Errorf("unknown capitalization scheme %q (use %s)", scheme, strings.Join(CapsSchemes, ", "))
+		}
+	}
+	return words, nil
+}
+
+// isCased reports whether r is a letter with a distinct upper-case form, so
+// that randomly capitalizing it makes a difference
+func isCased(r rune) bool {
+	return unicode.ToUpper(r) != r
+}
+
+// casedLetters returns the average number of isCased letters per word of
+// wordlist, which is the entropy in bits that CapsRandom adds to each word
+func casedLetters(wordlist []string) float64 {
+	if len(wordlist) == 0 {
+		return 0
+	}
+	total := 0
+	for _, word := range wordlist {
+		for _, r := range word {
+			if isCased(r) {
+				total++
+			}
+		}
+	}
+	return float64(total) / float64(len(wordlist))
+}
@@ -0,0 +1,83 @@
+package passinator
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+)
+
+// Argon2id parameters used to stretch the master secret. Changing any of them
+// changes every derived password, so they are fixed for the "v1" scheme
+const (
+	deterministicSaltPrefix = "pass-inator/v1/"
+	argon2Time              = 3
+	argon2Memory            = 64 * 1024
+	argon2Threads           = 4
+	argon2KeyLen            = chacha20.KeySize
+)
+
+// GenerateDeterministic derives a reproducible password for site from a
+// master secret: the same master, site and config always yield the same
+// password, so nothing needs to be stored.
+//
+// This does NOT use crypto/rand. The master secret is stretched with Argon2id,
+// salted with the site name, and the resulting key seeds a ChaCha20 key
+// stream that drives the same character selection and shuffle as
+// GeneratePassword. The password is only as strong as the master secret
+func GenerateDeterministic(master, site string, config PasswordConfig) (string, error) {
+	if master == "" {
+		return "", fmt.Errorf("master secret must not be empty")
+	}
+	if site == "" {
+		return "", fmt.Errorf("site must not be empty")
+	}
+
+	key := argon2.IDKey([]byte(master), []byte(deterministicSaltPrefix+site),
+		argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	stream, err := newKeyStream(key)
+	if err != nil {
+		return "", err
+	}
+	return generate(config, stream.intn, nil)
+}
+
+// keyStream produces deterministic random integers from a ChaCha20 key stream
+type keyStream struct {
+	cipher *chacha20.Cipher
+}
+
+// newKeyStream creates a keyStream seeded with key, using an all-zero nonce
+// since every key is used for a single password
+func newKeyStream(key []byte) (*keyStream, error) {
+	c, err := chacha20.NewUnauthenticatedCipher(key, make([]byte, chacha20.NonceSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise key stream: %w", err)
+	}
+	return &keyStream{cipher: c}, nil
+}
+
+// uint64 returns the next 8 bytes of the key stream as an integer
+func (s *keyStream) uint64() uint64 {
+	var buf [8]byte
+	s.cipher.XORKeyStream(buf[:], buf[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// intn returns a uniformly distributed integer in [0, max). Values from the
+// biased tail of the 64-bit range are rejected so every result is equally
+// likely
+func (s *keyStream) intn(max int) (int, error) {
+	if max <= 0 {
+		return 0, fmt.Errorf("max must be positive")
+	}
+	bound := uint64(max)
+	limit := -bound % bound // 2^64 mod bound
+	for {
+		v := s.uint64()
+		if v >= limit {
+			return int(v % bound), nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// isAlphanumeric reports whether r is a letter or digit, the characters
+// NoLeadingSpecial and NoTrailingSpecial allow at the ends of a password.
+// Everything else is special, as in AnalyzePassword
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// validateEdges checks that the character set can put an alphanumeric
+// character at the ends NoLeadingSpecial and NoTrailingSpecial constrain
+func validateEdges(config PasswordConfig, cats []category) error {
+	if (config.NoLeadingSpecial || config.NoTrailingSpecial) && !strings.ContainsFunc(charSet(cats), isAlphanumeric) {
+		return fmt.Errorf("keeping special characters off the ends requires letters or digits in the character set")
+	}
+	return nil
+}
+
+// fixEdges swaps a special character at a constrained end of password with
+// the nearest alphanumeric character that may move, keeping the shuffled
+// order otherwise intact. The first character is left alone when it is
+// itself constrained or must be a letter. It reports false when no suitable
+// character exists
+func fixEdges(password []rune, config PasswordConfig) bool {
+	last := len(password) - 1
+	if config.NoLeadingSpecial && !isAlphanumeric(password[0]) {
+		// The last character may only move when it is not constrained
+		end := last
+		if config.NoTrailingSpecial {
+			end = last - 1
+		}
+		i := 1
+		for i <= end && !isAlphanumeric(password[i]) {
+			i++
+		}
+		if i > end {
+			return false
+		}
+		password[0], password[i] = password[i], password[0]
+	}
+	if config.NoTrailingSpecial && !isAlphanumeric(password[last]) {
+		first := 0
+		if config.NoLeadingSpecial || config.MustStartWithLetter {
+			first = 1
+		}
+		i := last - 1
+		for i >= first && !isAlphanumeric(password[i]) {
+			i--
+		}
+		if i < first {
+			return false
+		}
+		password[last], password[i] = password[i], password[last]
+	}
+	return true
+}
+
+// edgesOK reports whether password satisfies NoLeadingSpecial and
+// NoTrailingSpecial
+func edgesOK(password []rune, config PasswordConfig) bool {
+	if len(password) == 0 {
+		return true
+	}
+	return !(config.NoLeadingSpecial && !isAlphanumeric(password[0])) &&
+		!(config.NoTrailingSpecial && !isAlphanumeric(password[len(password)-1]))
+}
@@ -0,0 +1,130 @@
+package passinator
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// EstimateEntropy returns the bits of entropy of a password generated with
+// config, computed as Length * log2(size of the effective character set), or
+// log2(size! / (size-Length)!) when characters may not repeat.
+//
+// With Balanced set, each character carries log2(types) bits for the choice of
+// type plus the average log2(size) of the types, which is less than log2 of
+// the combined size whenever the types differ in size. CategoryWeights work
+// the same way with the choice of type weighted, and lose more the further
+// the weights are from the type sizes. The small further loss from NoRepeats
+// is not counted in either case
+func EstimateEntropy(config PasswordConfig) float64 {
+	cats := categories(config)
+	size := CharsetSize(config)
+	if config.Length <= 0 || size == 0 {
+		return 0
+	}
+	if weights := categoryWeights(config, cats); weights != nil {
+		return float64(config.Length) * weightedEntropy(cats, weights)
+	}
+	if config.NoRepeats {
+		// Each position has one fewer candidate than the one before
+		bits := 0.0
+		for i := 0; i < config.Length && i < size; i++ {
+			bits += math.Log2(float64(size - i))
+		}
+		return bits
+	}
+	return float64(config.Length) * math.Log2(float64(size))
+}
+
+// CharsetSize returns the number of distinct characters a password generated
+// with config is drawn from: the selected types, or the custom character set,
+// minus ExcludeChars, ExcludeGroups and, for the built-in types, the
+// ambiguous characters when ExcludeAmbiguous is set
+func CharsetSize(config PasswordConfig) int {
+	return utf8.RuneCountInString(uniqueChars(charSet(categories(config))))
+}
+
+// weightedEntropy returns the bits of entropy of a single character drawn by
+// picking one of cats with probability proportional to its weight and then
+// one of its characters
+func weightedEntropy(cats []category, weights []int) float64 {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	bits := 0.0
+	for i, c := range cats {
+		if weights[i] == 0 {
+			continue
+		}
+		p := float64(weights[i]) / float64(total)
+		bits += p * (math.Log2(float64(utf8.RuneCountInString(c.chars))) - math.Log2(p))
+	}
+	return bits
+}
+
+// StrengthLabel classifies an entropy value in bits as Weak, Fair, Strong or
+// Very Strong
+func StrengthLabel(bits float64) string {
+	switch {
+	case bits < 50:
+		return "Weak"
+	case bits < 80:
+		return "Fair"
+	case bits < 128:
+		return "Strong"
+	default:
+		return "Very Strong"
+	}
+}
+
+// DefaultGuessesPerSecond is the attacker guess rate assumed by crack time
+// estimates when none is given
+const DefaultGuessesPerSecond = 1e10
+
+// CrackTimeEstimate converts entropy bits into a humanized expected time to
+// crack, such as "4 hours" or "centuries", for an attacker making
+// guessesPerSecond guesses. On average the password is found after searching
+// half of the keyspace, so 2^(bits-1) guesses are assumed
+func CrackTimeEstimate(entropyBits float64, guessesPerSecond float64) string {
+	if guessesPerSecond <= 0 {
+		guessesPerSecond = DefaultGuessesPerSecond
+	}
+	seconds := math.Exp2(entropyBits-1) / guessesPerSecond
+
+	const (
+		minute  = 60
+		hour    = 60 * minute
+		day     = 24 * hour
+		month   = 30 * day
+		year    = 365 * day
+		century = 100 * year
+	)
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds < minute:
+		return pluralize(seconds, "second")
+	case seconds < hour:
+		return pluralize(seconds/minute, "minute")
+	case seconds < day:
+		return pluralize(seconds/hour, "hour")
+	case seconds < month:
+		return pluralize(seconds/day, "day")
+	case seconds < year:
+		return pluralize(seconds/month, "month")
+	case seconds < century:
+		return pluralize(seconds/year, "year")
+	default:
+		return "centuries"
+	}
+}
+
+// pluralize formats n, rounded down, followed by unit in singular or plural
+func pluralize(n float64, unit string) string {
+	whole := int(n)
+	if whole == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", whole, unit)
+}
@@ -0,0 +1,63 @@
+package passinator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ExclusionGroups maps the name of each ExcludeGroups class to its
+// characters, for excluding a kind of symbol without listing every member
+var ExclusionGroups = map[string]string{
+	"brackets":    "()[]{}<>",
+	"quotes":      "'\"`",
+	"slashes":     "/\\|",
+	"punctuation": ".,;:!?",
+	"math":        "+-*=%^<>",
+	"currency":    "$£¥€¢¤",
+	// The accented letters of UnicodeChars, leaving its symbols
+	"accented": strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return r
+		}
+		return -1
+	}, UnicodeChars),
+}
+
+// ExclusionGroupNames returns the names of ExclusionGroups in sorted order
+func ExclusionGroupNames() []string {
+	names := make([]string, 0, len(ExclusionGroups))
+	for name := range ExclusionGroups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateExcludeGroups reports an error naming the first unknown group of
+// groups, along with the known ones
+func validateExcludeGroups(groups []string) error {
+	for _, group := range groups {
+		if _, ok := ExclusionGroups[group]; !ok {
+			return fmt.Errorf("unknown exclusion group %q (use %s)", group, strings.Join(ExclusionGroupNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// groupChars returns the combined characters of the named exclusion groups,
+// ignoring unknown names
+func groupChars(groups []string) string {
+	var chars strings.Builder
+	for _, group := range groups {
+		chars.WriteString(ExclusionGroups[group])
+	}
+	return chars.String()
+}
+
+// excludeGroups returns charSet without the characters of the named
+// exclusion groups
+func excludeGroups(charSet string, groups []string) string {
+	return removeChars(charSet, groupChars(groups))
+}
@@ -0,0 +1,36 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateGrouped creates a license-key style password of groups groups of
+// groupLen random characters joined by sep, such as "XK4PQ-9MZ2T-WR7JD". The
+// separators are part of the password but carry no randomness, so config is
+// applied to the groupLen*groups random characters alone: its Length and
+// MaxLength are ignored, and the category minimums and EstimateEntropy (with
+// Length set to that total) count only those characters
+func GenerateGrouped(groupLen, groups int, sep string, config PasswordConfig) (string, error) {
+	if groupLen < 1 {
+		return "", fmt.Errorf("group length must be at least 1")
+	}
+	if groups < 1 {
+		return "", fmt.Errorf("group count must be at least 1")
+	}
+
+	// A length range would spill past the last group
+	config.Length, config.MaxLength = groupLen*groups, 0
+	password, err := GeneratePassword(config)
+	if err != nil {
+		return "", err
+	}
+
+	runes := []rune(password)
+	defer wipeRunes(runes)
+	parts := make([]string, 0, groups)
+	for i := 0; i < len(runes); i += groupLen {
+		parts = append(parts, string(runes[i:i+groupLen]))
+	}
+	return strings.Join(parts, sep), nil
+}
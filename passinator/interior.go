@@ -0,0 +1,110 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// interiorCategories returns the selected types whose characters
+// InteriorRequired keeps at least one of away from the ends: uppercase and
+// special
+func interiorCategories(cats []category) []category {
+	var interior []category
+	for _, c := range cats {
+		if c.key == CategoryUppercase || c.key == CategorySpecial {
+			interior = append(interior, c)
+		}
+	}
+	return interior
+}
+
+// validateInterior checks that InteriorRequired has a type to place and
+// enough positions between the first and last character for one of each
+func validateInterior(config PasswordConfig, cats []category) error {
+	if !config.InteriorRequired {
+		return nil
+	}
+	interior := interiorCategories(cats)
+	if len(interior) == 0 {
+		return fmt.Errorf("interior placement requires uppercase or special characters to be selected")
+	}
+	if config.Length-2 < len(interior) {
+		return fmt.Errorf("%w: placing %d character types away from the ends needs at least %d characters", ErrTooShort, len(interior), len(interior)+2)
+	}
+	return nil
+}
+
+// hasInterior reports whether password has a character of c somewhere other
+// than its first and last position
+func hasInterior(password []rune, c category) bool {
+	for _, r := range password[1 : len(password)-1] {
+		if strings.ContainsRune(c.chars, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeInterior makes sure each of the uppercase and special types in cats
+// has a character in the interior of password. A type found only at the
+// ends has one of those characters swapped with a random interior character
+// that may take its place: one that keeps MustStartWithLetter,
+// NoLeadingSpecial and NoTrailingSpecial satisfied at that end and is not
+// the last interior character of another such type. It reports false when
+// no interior character may move
+func placeInterior(password []rune, cats []category, config PasswordConfig, randInt randIntFunc) (bool, error) {
+	interior := interiorCategories(cats)
+	last := len(password) - 1
+	// soleInterior reports whether r is the only interior character of its
+	// type, which must then stay where it is
+	soleInterior := func(r rune) bool {
+		for _, c := range interior {
+			if !strings.ContainsRune(c.chars, r) {
+				continue
+			}
+			n := 0
+			for _, s := range password[1:last] {
+				if strings.ContainsRune(c.chars, s) {
+					n++
+				}
+			}
+			return n == 1
+		}
+		return false
+	}
+	allowedAt := func(end int, r rune) bool {
+		if end == 0 {
+			return (!config.MustStartWithLetter || unicode.IsLetter(r)) &&
+				(!config.NoLeadingSpecial || isAlphanumeric(r))
+		}
+		return !config.NoTrailingSpecial || isAlphanumeric(r)
+	}
+
+	for _, c := range interior {
+		if hasInterior(password, c) {
+			continue
+		}
+		// Every type has at least one character, so this one is at an end
+		end := 0
+		if !strings.ContainsRune(c.chars, password[0]) {
+			end = last
+		}
+		var candidates []int
+		for i := 1; i < last; i++ {
+			if allowedAt(end, password[i]) && !soleInterior(password[i]) {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			return false, nil
+		}
+		idx, err := randInt(len(candidates))
+		if err != nil {
+			return false, fmt.Errorf("failed to generate random position: %w", err)
+		}
+		i := candidates[idx]
+		password[end], password[i] = password[i], password[end]
+	}
+	return true, nil
+}
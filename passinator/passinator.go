@@ -0,0 +1,877 @@
+// Package passinator generates cryptographically secure passwords using
+// crypto/rand. It backs the pass-inator command-line tool and can be imported
+// by other Go programs that need the same generation logic.
+package passinator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character sets and limits used for password generation
+const (
+	MinPasswordLength = 6
+	LowercaseChars    = "abcdefghijklmnopqrstuvwxyz"
+	UppercaseChars    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	NumberChars       = "0123456789"
+	SpecialChars      = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	AmbiguousChars    = "l1IO0o|B8S5Z2G6"
+
+	// UnicodeChars is a curated set of accented Latin letters and symbols
+	// that are single code points, printable, and render at normal width in
+	// most terminals. Not every system accepts them in passwords, and they
+	// can be hard to type on keyboards without the matching layout
+	UnicodeChars = "àáâäãåæçèéêëìíîïñòóôöõøùúûüýÿß" +
+		"ÀÁÂÄÃÅÆÇÈÉÊËÌÍÎÏÑÒÓÔÖÕØÙÚÛÜÝ" +
+		"§¶©®°±µ¿¡£¥€¢¤×÷"
+)
+
+// Alternative special character sets for sites that only accept some symbols
+const (
+	// SpecialCharsCommon holds the symbols accepted by most sites
+	SpecialCharsCommon = "!@#$%&*-_+=?"
+
+	// SpecialCharsSafe holds symbols that need no quoting or escaping in
+	// shells, URLs and file names
+	SpecialCharsSafe = "-_."
+
+	// SpecialCharsPOSIX holds the symbols a POSIX shell never interprets, so
+	// the password survives unquoted in scripts. Quotes, backticks, $, \,
+	// globbing and redirection characters are left out
+	SpecialCharsPOSIX = "%+,-./:=@_"
+
+	// SpecialCharsWindows holds the symbols that cmd.exe, PowerShell and
+	// connection strings leave alone. %, ^, &, |, <, >, quotes, backticks, $,
+	// ; and = are left out, as are # and @ (special in PowerShell) and the
+	// comma (an argument delimiter in cmd.exe)
+	SpecialCharsWindows = "+-./:_~"
+
+	// SpecialCharsWiFi holds the symbols that router setup pages, phone
+	// keyboards and the WIFI: QR code format handle without trouble. Quotes,
+	// backticks, \, $, &, <, >, |, brackets and space are left out, as are
+	// ;, : and the comma, which QR codes must escape
+	SpecialCharsWiFi = "!#%*+-.=?@_~"
+)
+
+// SpecialSets maps the name of each built-in special character set to its
+// characters. "all" is SpecialChars, the default
+var SpecialSets = map[string]string{
+	"all":               SpecialChars,
+	"common":            SpecialCharsCommon,
+	"alphanumeric-safe": SpecialCharsSafe,
+	"posix":             SpecialCharsPOSIX,
+	"windows":           SpecialCharsWindows,
+	"wifi":              SpecialCharsWiFi,
+}
+
+// PasswordConfig holds the configuration for password generation
+type PasswordConfig struct {
+	// Length is the number of characters (runes, i.e. Unicode code points) in
+	// the password, not bytes: utf8.RuneCountInString of the result always
+	// equals Length, while len of the result is larger when UseUnicode or a
+	// non-ASCII CustomCharset contributes multi-byte characters. The built-in
+	// character sets contain no combining marks, so each rune is also a
+	// single visible character
+	Length           int
+	UseLowercase     bool
+	UseUppercase     bool
+	UseNumbers       bool
+	UseSpecialChars  bool
+	UseUnicode       bool
+	Count            int
+	ExcludeAmbiguous bool
+
+	// Minimum number of characters of each type. A selected type always
+	// contributes at least one character, so values below 2 have no effect
+	MinLowercase int
+	MinUppercase int
+	MinNumbers   int
+	MinSpecial   int
+
+	// CustomCharset, when non-empty, replaces the built-in character types
+	// entirely. Duplicate characters are ignored
+	CustomCharset string
+
+	// SpecialCharset, when non-empty, replaces SpecialChars as the special
+	// character type, e.g. with one of SpecialSets. Duplicate characters are
+	// ignored
+	SpecialCharset string
+
+	// ExcludeChars lists characters that must never appear in the password
+	ExcludeChars string
+
+	// ExcludeGroups names classes of characters from ExclusionGroups, such as
+	// "brackets" or "quotes", that must never appear in the password
+	ExcludeGroups []string
+
+	// Blocklist holds banned substrings, such as a company name, that must
+	// not appear anywhere in the password, ignoring case. A password that
+	// contains one is generated again
+	Blocklist []string
+
+	// MaxConsecutive limits how many times a character may repeat in a row.
+	// Zero means unlimited
+	MaxConsecutive int
+
+	// MinLength is the shortest Length accepted. Zero means MinPasswordLength
+	MinLength int
+
+	// MaxLength, when above Length, makes Length the shortest of a range:
+	// every password gets its own length, drawn uniformly from Length to
+	// MaxLength inclusive, so lengths vary across a batch. Zero means every
+	// password is exactly Length characters. EstimateEntropy counts the
+	// shortest length
+	MaxLength int
+
+	// NoRepeats makes every character in the password unique
+	NoRepeats bool
+
+	// AvoidSequences re-rolls characters that would form an ascending or
+	// descending run of 3 or more in the alphabet, the digits or along a
+	// keyboard row, such as "abc", "987" or "qwe"
+	AvoidSequences bool
+
+	// Unique makes every password in a GeneratePasswords batch distinct,
+	// re-rolling duplicates
+	Unique bool
+
+	// MustStartWithLetter makes the first character a letter, for systems
+	// that reject passwords beginning with a digit or symbol
+	MustStartWithLetter bool
+
+	// NoLeadingSpecial and NoTrailingSpecial keep special characters off the
+	// first and last position, swapping them with a letter or digit from
+	// the interior
+	NoLeadingSpecial  bool
+	NoTrailingSpecial bool
+
+	// InteriorRequired keeps at least one of the required uppercase and
+	// special characters away from the first and last position, for
+	// policies that do not count a capital at the start or a symbol at the
+	// end
+	InteriorRequired bool
+
+	// Balanced gives every selected character type the same weight: each
+	// position first picks a type uniformly and then a character within it,
+	// instead of picking uniformly from the combined set. Symbols and digits
+	// then appear as often as letters, at the cost of some entropy per
+	// character since the smaller types are over-represented
+	Balanced bool
+
+	// CategoryWeights shapes the proportions of the selected character types,
+	// keyed by CategoryLowercase, CategoryUppercase, CategoryDigits,
+	// CategorySpecial and CategoryUnicode. Each position first picks a type
+	// with probability proportional to its weight, so {"digits": 3,
+	// "lowercase": 7} gives about 30% digits. Weights are relative, a
+	// selected type without a weight is only used for its minimum, and like
+	// Balanced this trades some entropy for the shaping
+	CategoryWeights map[string]float64
+
+	// MaxAttempts bounds every re-roll that a constraint may need: whole
+	// passwords for Unique, MustStartWithLetter and Blocklist, and single
+	// characters for AvoidSequences. Zero means DefaultMaxAttempts. Callers
+	// with constraints of their own can bound them the same way through
+	// AttemptLimit
+	MaxAttempts int
+}
+
+// DefaultMaxAttempts is the re-roll limit used when MaxAttempts is zero
+const DefaultMaxAttempts = 100
+
+// ErrGenerationExhausted is wrapped by the errors returned when the
+// configured constraints could not be met within MaxAttempts re-rolls. It
+// usually means the constraints are too strict for the length and character
+// set, so relaxing them, increasing the length or raising MaxAttempts helps
+var ErrGenerationExhausted = errors.New("generation attempts exhausted")
+
+// Errors wrapped by ValidateConfig, and so by the generators, for the
+// configurations callers most often need to tell apart with errors.Is
+var (
+	// ErrTooShort means Length is below the minimum length, or too short for
+	// the selected character types or their Min* counts
+	ErrTooShort = errors.New("password too short")
+	// ErrNoCategories means no character type is selected and there is no
+	// custom character set
+	ErrNoCategories = errors.New("at least one character type must be selected")
+	// ErrEmptyCharset means exclusions removed every character of a
+	// selected type or of the custom character set
+	ErrEmptyCharset = errors.New("no characters left to draw from")
+)
+
+// AttemptLimit returns the effective MaxAttempts of config, for callers that
+// re-roll passwords for constraints of their own
+func AttemptLimit(config PasswordConfig) int {
+	if config.MaxAttempts > 0 {
+		return config.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// minLength returns the effective minimum password length for config
+func minLength(config PasswordConfig) int {
+	if config.MinLength == 0 {
+		return MinPasswordLength
+	}
+	return config.MinLength
+}
+
+// RequiredLength returns the fewest characters that hold the per-category
+// minimums of config: the Min* count of each selected type, or 1 for a type
+// without one. A custom character set has no minimums
+func RequiredLength(config PasswordConfig) int {
+	required := 0
+	for _, c := range categories(config) {
+		required += c.min
+	}
+	return required
+}
+
+// ValidateConfig ensures the password configuration is valid
+func ValidateConfig(config PasswordConfig) error {
+	if config.MinLength < 0 {
+		return fmt.Errorf("minimum password length must not be negative")
+	}
+	if config.MaxAttempts < 0 {
+		return fmt.Errorf("maximum attempts must not be negative")
+	}
+	if config.MaxLength < 0 {
+		return fmt.Errorf("maximum password length must not be negative")
+	}
+	if config.MaxLength > 0 && config.MaxLength < config.Length {
+		return fmt.Errorf("maximum length %d is below the length %d", config.MaxLength, config.Length)
+	}
+	if config.MaxLength > config.Length {
+		// The start of the range is checked first, so that an error blames
+		// the end that causes it. Settings such as NoRepeats may still fail
+		// only at the longest length
+		shortest := config
+		shortest.MaxLength = 0
+		if err := ValidateConfig(shortest); err != nil {
+			return err
+		}
+		longest := config
+		longest.Length, longest.MaxLength = config.MaxLength, 0
+		if err := ValidateConfig(longest); err != nil {
+			return fmt.Errorf("at the maximum length of %d: %w", config.MaxLength, err)
+		}
+		return nil
+	}
+	if err := validateExcludeGroups(config.ExcludeGroups); err != nil {
+		return err
+	}
+	if err := validateBlocklist(config.Blocklist); err != nil {
+		return err
+	}
+	if config.Length < 1 || config.Length < minLength(config) {
+		return fmt.Errorf("%w: length must be at least %d characters", ErrTooShort, max(1, minLength(config)))
+	}
+	if config.CustomCharset != "" {
+		return validateCustomCharset(config)
+	}
+	if !config.UseLowercase && !config.UseUppercase && !config.UseNumbers && !config.UseSpecialChars && !config.UseUnicode {
+		return ErrNoCategories
+	}
+
+	minimums := []struct {
+		name    string
+		enabled bool
+		min     int
+	}{
+		{"lowercase", config.UseLowercase, config.MinLowercase},
+		{"uppercase", config.UseUppercase, config.MinUppercase},
+		{"number", config.UseNumbers, config.MinNumbers},
+		{"special", config.UseSpecialChars, config.MinSpecial},
+	}
+	for _, m := range minimums {
+		if m.min < 0 {
+			return fmt.Errorf("minimum %s characters must not be negative", m.name)
+		}
+		if m.min > 0 && !m.enabled {
+			return fmt.Errorf("minimum %s characters requires %s characters to be selected", m.name, m.name)
+		}
+	}
+
+	if config.MaxConsecutive < 0 {
+		return fmt.Errorf("maximum consecutive characters must not be negative")
+	}
+
+	cats := categories(config)
+	if len(cats) > config.Length {
+		return fmt.Errorf("%w: %d character types are selected but the length is only %d", ErrTooShort, len(cats), config.Length)
+	}
+
+	required := 0
+	for _, c := range cats {
+		if c.chars == "" {
+			return fmt.Errorf("%w: all %s characters are excluded", ErrEmptyCharset, c.name)
+		}
+		// Re-rolling a repeat needs a different character of the same type
+		if config.MaxConsecutive > 0 && utf8.RuneCountInString(c.chars) < 2 {
+			return fmt.Errorf("limiting consecutive characters requires at least 2 %s characters", c.name)
+		}
+		if config.NoRepeats && c.min > utf8.RuneCountInString(c.chars) {
+			return fmt.Errorf("cannot pick %d distinct %s characters from %d available", c.min, c.name, utf8.RuneCountInString(c.chars))
+		}
+		required += c.min
+	}
+	if required > config.Length {
+		return fmt.Errorf("%w: per-category minimums require %d characters but the length is %d", ErrTooShort, required, config.Length)
+	}
+	if err := validateStartLetter(config, cats); err != nil {
+		return err
+	}
+	if err := validateEdges(config, cats); err != nil {
+		return err
+	}
+	if err := validateInterior(config, cats); err != nil {
+		return err
+	}
+	if err := validateWeights(config, cats); err != nil {
+		return err
+	}
+	return validateNoRepeats(config, cats)
+}
+
+// validateWeights checks that CategoryWeights only weights selected types,
+// with non-negative finite values of which at least one is positive
+func validateWeights(config PasswordConfig, cats []category) error {
+	if len(config.CategoryWeights) == 0 {
+		return nil
+	}
+	if config.Balanced {
+		return fmt.Errorf("category weights cannot be combined with balanced sampling")
+	}
+	total := 0.0
+	for key, weight := range config.CategoryWeights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("weight of %s must be a non-negative number", key)
+		}
+		selected := false
+		for _, c := range cats {
+			if c.key == key {
+				selected = true
+			}
+		}
+		if !selected {
+			return fmt.Errorf("weight given for %s, which is not a selected character type", key)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one category weight must be positive")
+	}
+	return nil
+}
+
+// validateStartLetter checks that a password required to start with a letter
+// can contain one
+func validateStartLetter(config PasswordConfig, cats []category) error {
+	if config.MustStartWithLetter && !strings.ContainsFunc(charSet(cats), unicode.IsLetter) {
+		return fmt.Errorf("starting with a letter requires letters in the character set")
+	}
+	return nil
+}
+
+// validateNoRepeats checks that there are enough distinct characters for a
+// password without repeats
+func validateNoRepeats(config PasswordConfig, cats []category) error {
+	size := utf8.RuneCountInString(charSet(cats))
+	if config.NoRepeats && config.Length > size {
+		return fmt.Errorf("password length %d exceeds the %d distinct characters available without repeats", config.Length, size)
+	}
+	return nil
+}
+
+// validateCustomCharset checks the parts of config that apply when a custom
+// character set is used
+func validateCustomCharset(config PasswordConfig) error {
+	if config.MaxConsecutive < 0 {
+		return fmt.Errorf("maximum consecutive characters must not be negative")
+	}
+	switch utf8.RuneCountInString(charSet(categories(config))) {
+	case 0:
+		return fmt.Errorf("%w: every character of the custom set is excluded", ErrEmptyCharset)
+	case 1:
+		return fmt.Errorf("custom character set must contain at least 2 distinct characters")
+	}
+	if err := validateNoRepeats(config, categories(config)); err != nil {
+		return err
+	}
+	if err := validateStartLetter(config, categories(config)); err != nil {
+		return err
+	}
+	if err := validateEdges(config, categories(config)); err != nil {
+		return err
+	}
+	if config.MinLowercase > 0 || config.MinUppercase > 0 || config.MinNumbers > 0 || config.MinSpecial > 0 {
+		return fmt.Errorf("per-category minimums cannot be combined with a custom character set")
+	}
+	if len(config.CategoryWeights) > 0 {
+		return fmt.Errorf("category weights cannot be combined with a custom character set")
+	}
+	if config.InteriorRequired {
+		return fmt.Errorf("interior placement cannot be combined with a custom character set")
+	}
+	return nil
+}
+
+// uniqueChars returns set with duplicate characters removed, keeping the first
+// occurrence of each
+func uniqueChars(set string) string {
+	seen := make(map[rune]bool)
+	return strings.Map(func(r rune) rune {
+		if seen[r] {
+			return -1
+		}
+		seen[r] = true
+		return r
+	}, set)
+}
+
+// removeChars returns set with every character found in exclude removed
+func removeChars(set, exclude string) string {
+	// Nothing is excluded in the common case, which should not cost a copy
+	if exclude == "" {
+		return set
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}, set)
+}
+
+// category is a selected character type together with the number of its
+// characters the password must contain
+type category struct {
+	name  string
+	key   string // the CategoryWeights key
+	chars string
+	min   int
+}
+
+// categories returns each selected character type, with any excluded
+// characters and exclusion groups already removed. A custom character set is
+// returned as a single category without a minimum, since the built-in types
+// do not apply to it
+func categories(config PasswordConfig) []category {
+	if config.CustomCharset != "" {
+		chars := excludeGroups(removeChars(uniqueChars(config.CustomCharset), config.ExcludeChars), config.ExcludeGroups)
+		return []category{{"custom", "", chars, 0}}
+	}
+
+	var cats []category
+	if config.UseLowercase {
+		cats = append(cats, category{"lowercase", CategoryLowercase, LowercaseChars, max(1, config.MinLowercase)})
+	}
+	if config.UseUppercase {
+		cats = append(cats, category{"uppercase", CategoryUppercase, UppercaseChars, max(1, config.MinUppercase)})
+	}
+	if config.UseNumbers {
+		cats = append(cats, category{"number", CategoryDigits, NumberChars, max(1, config.MinNumbers)})
+	}
+	if config.UseSpecialChars {
+		special := SpecialChars
+		if config.SpecialCharset != "" {
+			special = uniqueChars(config.SpecialCharset)
+		}
+		cats = append(cats, category{"special", CategorySpecial, special, max(1, config.MinSpecial)})
+	}
+	if config.UseUnicode {
+		cats = append(cats, category{"unicode", CategoryUnicode, UnicodeChars, 1})
+	}
+	exclude := config.ExcludeChars
+	if config.ExcludeAmbiguous {
+		exclude += AmbiguousChars
+	}
+	for i := range cats {
+		cats[i].chars = excludeGroups(removeChars(cats[i].chars, exclude), config.ExcludeGroups)
+	}
+	return cats
+}
+
+// charSet returns the combined characters of every selected type
+func charSet(cats []category) string {
+	var set strings.Builder
+	for _, c := range cats {
+		set.WriteString(c.chars)
+	}
+	return set.String()
+}
+
+// randIntFunc returns a random integer in [0, max)
+type randIntFunc func(max int) (int, error)
+
+// GeneratePassword creates a password based on the provided configuration
+func GeneratePassword(config PasswordConfig) (string, error) {
+	src := newRandomSource()
+	defer src.wipe()
+	return generate(config, src.intn, src)
+}
+
+// errRetry is returned by generateOnce when the characters drawn cannot
+// satisfy MustStartWithLetter, NoLeadingSpecial, NoTrailingSpecial or
+// InteriorRequired, or form a Blocklist entry, so generation must start over
+var errRetry = errors.New("characters drawn cannot satisfy the constraints")
+
+// generate creates a password based on config, drawing every random choice
+// from randInt. src is the randomSource behind randInt, or nil when randInt
+// is not backed by one, as in deterministic mode; when set, the common
+// alphanumeric fill reads its bytes directly
+func generate(config PasswordConfig, randInt randIntFunc, src *randomSource) (string, error) {
+	if config.MaxLength > config.Length {
+		// Checked first, so that a range starting too short fails every time
+		// rather than only when a short length is drawn
+		if err := ValidateConfig(config); err != nil {
+			return "", err
+		}
+		// Drawn once per password, so that the re-rolls below never favour
+		// the lengths that satisfy the constraints more easily
+		n, err := randInt(config.MaxLength - config.Length + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random length: %w", err)
+		}
+		config.Length, config.MaxLength = config.Length+n, 0
+	}
+	for attempt := 1; ; attempt++ {
+		password, err := generateOnce(config, randInt, src)
+		if !errors.Is(err, errRetry) {
+			return password, err
+		}
+		// Too few letters or digits were drawn, which is only likely when the
+		// set has few of them, or a banned word came up, so start over
+		if attempt >= AttemptLimit(config) {
+			return "", fmt.Errorf("no password with the required first and last characters and no blocklisted word in %d attempts: %w", attempt, ErrGenerationExhausted)
+		}
+	}
+}
+
+// generateOnce makes a single attempt at the password generate returns
+func generateOnce(config PasswordConfig, randInt randIntFunc, src *randomSource) (string, error) {
+	if err := ValidateConfig(config); err != nil {
+		return "", err
+	}
+
+	// Build character set based on configuration
+	cats := categories(config)
+	set := charSet(cats)
+	chars := []rune(set)
+
+	// Ensure the minimum number of characters from each selected type
+	passwordRunes := make([]rune, 0, config.Length)
+	// Runs after the returned string has been copied out of the buffer
+	defer func() { wipeRunes(passwordRunes) }()
+	for _, c := range cats {
+		// Never seed more characters than the requested length
+		n := min(c.min, config.Length-len(passwordRunes))
+		seeded, err := drawChars([]rune(c.chars), n, config.NoRepeats, randInt)
+		if err != nil {
+			return "", err
+		}
+		passwordRunes = append(passwordRunes, seeded...)
+		wipeRunes(seeded)
+	}
+
+	// Fill the rest of the password with random characters
+	remainingLength := config.Length - len(passwordRunes)
+	if remainingLength < 0 {
+		return "", fmt.Errorf("seeded %d characters but password length is %d", len(passwordRunes), config.Length)
+	}
+	var filled []rune
+	var err error
+	if weights := categoryWeights(config, cats); weights != nil {
+		filled, err = drawWeighted(cats, weights, remainingLength, passwordRunes, config.NoRepeats, randInt)
+	} else if src != nil && !config.NoRepeats && set == alphanumericChars {
+		filled, err = src.alphanumeric(remainingLength)
+	} else {
+		pool := chars
+		if config.NoRepeats {
+			pool = []rune(removeChars(set, string(passwordRunes)))
+		}
+		filled, err = drawChars(pool, remainingLength, config.NoRepeats, randInt)
+	}
+	if err != nil {
+		return "", err
+	}
+	passwordRunes = append(passwordRunes, filled...)
+	wipeRunes(filled)
+
+	// Shuffle the password using Fisher-Yates algorithm with crypto/rand
+	for i := len(passwordRunes) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to shuffle password: %w", err)
+		}
+		passwordRunes[i], passwordRunes[j] = passwordRunes[j], passwordRunes[i]
+	}
+
+	// Done before limitConsecutive, which never changes the first character
+	if config.MustStartWithLetter && !startWithLetter(passwordRunes) {
+		return "", errRetry
+	}
+	if !fixEdges(passwordRunes, config) {
+		return "", errRetry
+	}
+	// After the ends are settled, so that nothing moves a placed character
+	// back; the re-rolls below keep each character's type
+	if config.InteriorRequired {
+		placed, err := placeInterior(passwordRunes, cats, config, randInt)
+		if err != nil {
+			return "", err
+		}
+		if !placed {
+			return "", errRetry
+		}
+	}
+
+	if config.MaxConsecutive > 0 {
+		if err := limitConsecutive(passwordRunes, cats, config.MaxConsecutive, randInt); err != nil {
+			return "", err
+		}
+	}
+	if config.AvoidSequences {
+		if err := avoidSequences(passwordRunes, cats, config, randInt); err != nil {
+			return "", err
+		}
+	}
+
+	// Never hand out an empty password, whatever the configuration
+	if len(passwordRunes) == 0 {
+		return "", fmt.Errorf("generated password is empty")
+	}
+	// The re-rolls above keep each character's type, but a custom set is a
+	// single type that may put a special character back at an end
+	if !edgesOK(passwordRunes, config) {
+		return "", errRetry
+	}
+	password := string(passwordRunes)
+	if containsBlocked(password, config.Blocklist) {
+		return "", errRetry
+	}
+	return password, nil
+}
+
+// startWithLetter swaps the first letter of password to the front, keeping the
+// shuffled order otherwise intact. It reports false when password contains no
+// letter at all
+func startWithLetter(password []rune) bool {
+	for i, r := range password {
+		if unicode.IsLetter(r) {
+			password[0], password[i] = password[i], password[0]
+			return true
+		}
+	}
+	return false
+}
+
+// drawChars picks n random characters from set. With distinct set it samples
+// without replacement using a partial Fisher-Yates shuffle, so no character
+// is picked twice
+func drawChars(set []rune, n int, distinct bool, randInt randIntFunc) ([]rune, error) {
+	if !distinct {
+		drawn := make([]rune, 0, n)
+		for i := 0; i < n; i++ {
+			idx, err := randInt(len(set))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate random index: %w", err)
+			}
+			drawn = append(drawn, set[idx])
+		}
+		return drawn, nil
+	}
+
+	if n > len(set) {
+		return nil, fmt.Errorf("cannot pick %d distinct characters from %d", n, len(set))
+	}
+	pool := append([]rune(nil), set...)
+	for i := 0; i < n; i++ {
+		j, err := randInt(len(pool) - i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate random index: %w", err)
+		}
+		pool[i], pool[i+j] = pool[i+j], pool[i]
+	}
+	return pool[:n], nil
+}
+
+// weightScale is the resolution at which CategoryWeights are turned into
+// integer weights for sampling
+const weightScale = 1_000_000
+
+// categoryWeights returns the integer sampling weight of each of cats: equal
+// weights for Balanced, CategoryWeights normalized to weightScale, or nil when
+// characters are picked uniformly from the combined set
+func categoryWeights(config PasswordConfig, cats []category) []int {
+	if config.Balanced {
+		weights := make([]int, len(cats))
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights
+	}
+	if len(config.CategoryWeights) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, c := range cats {
+		total += config.CategoryWeights[c.key]
+	}
+	if total <= 0 {
+		// Rejected by ValidateConfig
+		return nil
+	}
+	weights := make([]int, len(cats))
+	for i, c := range cats {
+		weights[i] = int(math.Round(config.CategoryWeights[c.key] / total * weightScale))
+	}
+	return weights
+}
+
+// drawWeighted picks n random characters by first choosing one of cats with
+// probability proportional to its weight and then a character within it.
+// With distinct set, characters in used and those already drawn are never
+// picked, and types with nothing left drop out of the choice
+func drawWeighted(cats []category, weights []int, n int, used []rune, distinct bool, randInt randIntFunc) ([]rune, error) {
+	pools := make([][]rune, 0, len(cats))
+	for _, c := range cats {
+		chars := c.chars
+		if distinct {
+			chars = removeChars(chars, string(used))
+		}
+		pools = append(pools, []rune(chars))
+	}
+
+	drawn := make([]rune, 0, n)
+	for i := 0; i < n; i++ {
+		total := 0
+		for p, pool := range pools {
+			if len(pool) > 0 {
+				total += weights[p]
+			}
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("cannot pick %d distinct characters", n)
+		}
+		r, err := randInt(total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate random index: %w", err)
+		}
+		p := 0
+		for ; len(pools[p]) == 0 || r >= weights[p]; p++ {
+			if len(pools[p]) > 0 {
+				r -= weights[p]
+			}
+		}
+		pool := pools[p]
+		idx, err := randInt(len(pool))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate random index: %w", err)
+		}
+		drawn = append(drawn, pool[idx])
+		if distinct {
+			pools[p] = append(pool[:idx:idx], pool[idx+1:]...)
+		}
+	}
+	return drawn, nil
+}
+
+// limitConsecutive re-rolls characters that would extend a run of identical
+// characters beyond maxRun. A replacement is drawn from the same type as the
+// character it replaces, excluding that character, so per-type minimums are
+// preserved and every re-roll is guaranteed to break the run
+func limitConsecutive(password []rune, cats []category, maxRun int, randInt randIntFunc) error {
+	run := 1
+	for i := 1; i < len(password); i++ {
+		if password[i] != password[i-1] {
+			run = 1
+			continue
+		}
+		run++
+		if run <= maxRun {
+			continue
+		}
+
+		var candidates []rune
+		for _, c := range cats {
+			if strings.ContainsRune(c.chars, password[i]) {
+				candidates = []rune(removeChars(c.chars, string(password[i])))
+				break
+			}
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no replacement available for repeated character")
+		}
+		idx, err := randInt(len(candidates))
+		if err != nil {
+			return fmt.Errorf("failed to generate random index: %w", err)
+		}
+		password[i] = candidates[idx]
+		run = 1
+	}
+	return nil
+}
+
+// ValidateBatch checks, before any password is generated, that a batch of
+// count passwords can be generated with config: the settings must be valid
+// and, with Unique, there must be room for count distinct passwords. It is
+// the check GeneratePasswords and WritePasswords make, for callers that
+// generate a batch themselves
+func ValidateBatch(config PasswordConfig, count int) error {
+	if count <= 0 {
+		return fmt.Errorf("password count must be at least 1")
+	}
+	// Settings errors are reported once instead of on the first password
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+	// The estimate ignores per-type minimums, so the real keyspace can be
+	// smaller; BatchAttempts bounds those cases
+	if bits := EstimateEntropy(config); config.Unique && math.Log2(float64(count)) > bits {
+		return fmt.Errorf("cannot generate %d distinct passwords: only about %.0f are possible", count, math.Exp2(bits))
+	}
+	return nil
+}
+
+// BatchAttempts returns how many passwords may be generated for a batch of
+// count before giving up with ErrGenerationExhausted, which Unique needs when
+// the keyspace is almost exhausted. It is bounded per requested password, so
+// that such a batch fails instead of looping for a long time
+func BatchAttempts(config PasswordConfig, count int) int {
+	return count * AttemptLimit(config)
+}
+
+// GeneratePasswords creates config.Count passwords, each with fresh randomness
+func GeneratePasswords(config PasswordConfig) ([]string, error) {
+	if err := ValidateBatch(config, config.Count); err != nil {
+		return nil, err
+	}
+
+	// Share one source so the whole batch is drawn from a few large reads
+	src := newRandomSource()
+	defer src.wipe()
+	passwords := make([]string, 0, config.Count)
+	seen := make(map[string]bool)
+	for attempts := 0; len(passwords) < config.Count; attempts++ {
+		if attempts >= BatchAttempts(config, config.Count) {
+			return nil, fmt.Errorf("could only generate %d distinct passwords out of %d requested: %w", len(passwords), config.Count, ErrGenerationExhausted)
+		}
+		password, err := generate(config, src.intn, src)
+		if err != nil {
+			return nil, err
+		}
+		if config.Unique {
+			if seen[password] {
+				continue
+			}
+			seen[password] = true
+		}
+		passwords = append(passwords, password)
+	}
+	return passwords, nil
+}
@@ -0,0 +1,288 @@
+package passinator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PassphraseConfig holds the configuration for passphrase generation
+type PassphraseConfig struct {
+	Words        int
+	Separator    string
+	Capitalize   bool
+	AppendNumber bool
+	// InjectDigits and InjectSpecials insert that many random digits and
+	// special characters at random positions of the finished passphrase, to
+	// satisfy policies that require them
+	InjectDigits   int
+	InjectSpecials int
+	// Caps is the capitalization scheme, one of CapsSchemes. Empty means
+	// CapsFirst when Capitalize is set and CapsNone otherwise
+	Caps string
+	// Wordlist names the embedded wordlist to draw from, one of Wordlists();
+	// empty means DefaultWordlist
+	Wordlist string
+}
+
+// GeneratePassphrase picks wordCount random words from the default embedded
+// wordlist and joins them with separator
+func GeneratePassphrase(wordCount int, separator string) (string, error) {
+	return GenerateCustomPassphrase(PassphraseConfig{
+		Words:     wordCount,
+		Separator: separator,
+	})
+}
+
+// GenerateCustomPassphrase creates a passphrase based on the provided
+// configuration. Caps (or Capitalize) sets how the words are capitalized and
+// AppendNumber adds a random digit to the end of the passphrase
+func GenerateCustomPassphrase(config PassphraseConfig) (string, error) {
+	passphrase, _, err := generatePassphrase(config, 0)
+	return passphrase, err
+}
+
+// GeneratePassphraseMinLen picks words from the default embedded wordlist
+// and joins them with sep, adding words until the passphrase is at least
+// minLen characters long, and returns it with the number of words used
+func GeneratePassphraseMinLen(minLen int, sep string) (string, int, error) {
+	return GeneratePassphraseMinLenFrom(PassphraseConfig{Words: 1, Separator: sep}, minLen)
+}
+
+// GeneratePassphraseMinLenFrom works like GenerateCustomPassphrase but keeps
+// adding words past config.Words until the finished passphrase, including
+// any appended and injected characters, is at least minLen characters long.
+// It returns the passphrase with the number of words used
+func GeneratePassphraseMinLenFrom(config PassphraseConfig, minLen int) (string, int, error) {
+	if minLen < 1 {
+		return "", 0, fmt.Errorf("minimum passphrase length must be at least 1")
+	}
+	return generatePassphrase(config, minLen)
+}
+
+// generatePassphrase builds a passphrase of at least config.Words words and
+// of at least minLen characters, and returns it with the number of words
+func generatePassphrase(config PassphraseConfig, minLen int) (string, int, error) {
+	if config.Words < 1 {
+		return "", 0, fmt.Errorf("passphrase must contain at least 1 word")
+	}
+	scheme, err := capsScheme(config)
+	if err != nil {
+		return "", 0, err
+	}
+	wordlist, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Characters added after the words are joined count towards minLen too
+	extra := config.InjectDigits + config.InjectSpecials
+	if config.AppendNumber {
+		extra++
+	}
+	separatorLen := utf8.RuneCountInString(config.Separator)
+	words := make([]string, 0, config.Words)
+	length := 0
+	for len(words) < config.Words || length+extra < minLen {
+		idx, err := secureRandomInt(len(wordlist))
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to generate random index: %w", err)
+		}
+		if len(words) > 0 {
+			length += separatorLen
+		}
+		words = append(words, wordlist[idx])
+		length += utf8.RuneCountInString(wordlist[idx])
+	}
+	if words, err = applyCaps(words, scheme); err != nil {
+		return "", 0, err
+	}
+
+	passphrase := strings.Join(words, config.Separator)
+	if config.AppendNumber {
+		idx, err := secureRandomInt(len(NumberChars))
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to generate random index: %w", err)
+		}
+		passphrase += string(NumberChars[idx])
+	}
+
+	passphrase, err = injectRandomChars(passphrase, config.InjectDigits, config.InjectSpecials)
+	return passphrase, len(words), err
+}
+
+// injectRandomChars inserts digits random digits and specials random special
+// characters into s, each at a position chosen with secureRandomInt among
+// every gap between the characters of s, including both ends
+func injectRandomChars(s string, digits, specials int) (string, error) {
+	if digits < 0 || specials < 0 {
+		return "", fmt.Errorf("number of injected characters must not be negative")
+	}
+	runes := []rune(s)
+	for i := 0; i < digits+specials; i++ {
+		chars := NumberChars
+		if i >= digits {
+			chars = SpecialChars
+		}
+		idx, err := secureRandomInt(len(chars))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		pos, err := secureRandomInt(len(runes) + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random position: %w", err)
+		}
+		runes = slices.Insert(runes, pos, rune(chars[idx]))
+	}
+	return string(runes), nil
+}
+
+// PassphraseEntropy returns the bits of entropy of a GenerateCustomPassphrase
+// passphrase: log2 of the wordlist size per word, plus the average number of
+// letters per word with CapsRandom, since each letter's case is a coin flip,
+// plus log2(10) with AppendNumber. The other schemes and the separator are
+// fixed and add nothing. Each injected character adds the choice of the
+// character and of its position, counting positions in the shortest possible
+// passphrase so that varying word lengths never overstate the result
+func PassphraseEntropy(config PassphraseConfig) (float64, error) {
+	scheme, err := capsScheme(config)
+	if err != nil {
+		return 0, err
+	}
+	wordlist, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return 0, err
+	}
+	if config.Words < 1 {
+		return 0, nil
+	}
+	perWord := math.Log2(float64(len(wordlist)))
+	if scheme == CapsRandom {
+		perWord += casedLetters(wordlist)
+	}
+	bits := float64(config.Words) * perWord
+	if config.AppendNumber {
+		bits += math.Log2(float64(len(NumberChars)))
+	}
+
+	if config.InjectDigits < 0 || config.InjectSpecials < 0 {
+		return 0, fmt.Errorf("number of injected characters must not be negative")
+	}
+	shortest := utf8.RuneCountInString(wordlist[0])
+	for _, word := range wordlist {
+		shortest = min(shortest, utf8.RuneCountInString(word))
+	}
+	length := config.Words*shortest + (config.Words-1)*utf8.RuneCountInString(config.Separator)
+	if config.AppendNumber {
+		length++
+	}
+	for i := 0; i < config.InjectDigits+config.InjectSpecials; i++ {
+		chars := NumberChars
+		if i >= config.InjectDigits {
+			chars = SpecialChars
+		}
+		bits += math.Log2(float64(len(chars))) + math.Log2(float64(length+1))
+		length++
+	}
+	return bits, nil
+}
+
+// PassphraseMinLenEntropy returns the bits of entropy of a
+// GeneratePassphraseMinLenFrom passphrase. The number of words varies, so
+// it counts the fewest words that could ever reach minLen, using the longest
+// word of the list, or config.Words if that is more, so that it never counts
+// a word a passphrase may not have
+func PassphraseMinLenEntropy(config PassphraseConfig, minLen int) (float64, error) {
+	wordlist, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return 0, err
+	}
+	longest := 0
+	for _, word := range wordlist {
+		longest = max(longest, utf8.RuneCountInString(word))
+	}
+	extra := config.InjectDigits + config.InjectSpecials
+	if config.AppendNumber {
+		extra++
+	}
+	separatorLen := utf8.RuneCountInString(config.Separator)
+	config.Words = max(config.Words, 1)
+	for config.Words*longest+(config.Words-1)*separatorLen+extra < minLen {
+		config.Words++
+	}
+	return PassphraseEntropy(config)
+}
+
+// capitalize upper-cases the first letter of word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// Separator and number range used by GenerateMemorable
+const (
+	memorableSeparator = "-"
+	memorableNumberMin = 10
+	memorableNumberMax = 99
+)
+
+// GenerateMemorable creates a passphrase of title-cased words followed by a
+// random 2-digit number and a special character, such as "Tiger-Cloud-42!"
+func GenerateMemorable(words int) (string, error) {
+	return GenerateMemorableFrom(DefaultWordlist, words)
+}
+
+// GenerateMemorableFrom works like GenerateMemorable but draws the words from
+// the named embedded wordlist
+func GenerateMemorableFrom(wordlist string, words int) (string, error) {
+	passphrase, err := GenerateCustomPassphrase(PassphraseConfig{
+		Words:      words,
+		Separator:  memorableSeparator,
+		Capitalize: true,
+		Wordlist:   wordlist,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	number, err := secureRandomInt(memorableNumberMax - memorableNumberMin + 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random number: %w", err)
+	}
+	special, err := secureRandomInt(len(SpecialChars))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random index: %w", err)
+	}
+
+	return fmt.Sprintf("%s%s%d%c", passphrase, memorableSeparator, memorableNumberMin+number, SpecialChars[special]), nil
+}
+
+// MemorableEntropy returns the bits of entropy of a GenerateMemorable
+// passphrase with the given number of words. Capitalization and separators
+// are fixed and add nothing, so only the word, number and symbol choices
+// count
+func MemorableEntropy(words int) float64 {
+	bits, _ := MemorableEntropyFrom(DefaultWordlist, words)
+	return bits
+}
+
+// MemorableEntropyFrom works like MemorableEntropy for a passphrase drawn
+// from the named embedded wordlist, whose size sets the bits per word
+func MemorableEntropyFrom(wordlist string, words int) (float64, error) {
+	list, err := loadWordlist(wordlist)
+	if err != nil {
+		return 0, err
+	}
+	if words < 1 {
+		return 0, nil
+	}
+	return float64(words)*math.Log2(float64(len(list))) +
+		math.Log2(memorableNumberMax-memorableNumberMin+1) +
+		math.Log2(float64(len(SpecialChars))), nil
+}
@@ -0,0 +1,137 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// patternPlaceholders maps each pattern placeholder to the characters it is
+// replaced with
+var patternPlaceholders = map[rune]string{
+	'A': UppercaseChars,
+	'a': LowercaseChars,
+	'#': NumberChars,
+	'$': SpecialChars,
+}
+
+// Word placeholders recognised by GeneratePassphrasePattern
+const (
+	wordPlaceholder            = "word"
+	capitalizedWordPlaceholder = "Word"
+)
+
+// GenerateFromPattern creates a password following pattern, where A is an
+// uppercase letter, a a lowercase letter, # a digit and $ a special character.
+// Any other non-letter character is copied through literally, and a backslash
+// copies the next character literally, so "Aa#aaaa-###" yields e.g.
+// "Kd4mxqe-902". Letters other than the placeholders are rejected so that
+// typos are not silently treated as literals
+func GenerateFromPattern(pattern string) (string, error) {
+	return expandPattern(pattern, nil)
+}
+
+// GeneratePassphrasePattern works like GenerateFromPattern but also replaces
+// each "word" with a random word from the default embedded wordlist and each
+// "Word" with a capitalized one, so "word-word-##-$" yields e.g.
+// "cobalt-unzip-47-&"
+func GeneratePassphrasePattern(pattern string) (string, error) {
+	return GeneratePassphrasePatternFrom(DefaultWordlist, pattern)
+}
+
+// GeneratePassphrasePatternFrom works like GeneratePassphrasePattern but
+// draws the words from the named embedded wordlist
+func GeneratePassphrasePatternFrom(wordlist, pattern string) (string, error) {
+	words, err := loadWordlist(wordlist)
+	if err != nil {
+		return "", err
+	}
+	return expandPattern(pattern, words)
+}
+
+// WordNumberWordPattern is the GeneratePassphrasePattern pattern of
+// GenerateWordNumberWord
+const WordNumberWordPattern = "word#word"
+
+// GenerateWordNumberWord creates a readable name such as "correct7horse": two
+// random words from the default wordlist joined by a random digit. At about
+// 29 bits it suits service account names and similar identifiers rather than
+// secrets
+func GenerateWordNumberWord() (string, error) {
+	return GeneratePassphrasePattern(WordNumberWordPattern)
+}
+
+// expandPattern implements GenerateFromPattern and, when words is not nil,
+// GeneratePassphrasePattern drawing from words
+func expandPattern(pattern string, words []string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("pattern must not be empty")
+	}
+
+	var password strings.Builder
+	escaped := false
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		if escaped {
+			password.WriteRune(r)
+			escaped = false
+			i += size
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			i += size
+			continue
+		}
+
+		if words != nil {
+			if placeholder, capital := wordAt(pattern[i:]); placeholder != "" {
+				idx, err := secureRandomInt(len(words))
+				if err != nil {
+					return "", fmt.Errorf("failed to generate random index: %w", err)
+				}
+				word := words[idx]
+				if capital {
+					word = capitalize(word)
+				}
+				password.WriteString(word)
+				i += len(placeholder)
+				continue
+			}
+		}
+
+		chars, ok := patternPlaceholders[r]
+		if !ok {
+			if unicode.IsLetter(r) {
+				return "", fmt.Errorf("unknown pattern placeholder %q", r)
+			}
+			password.WriteRune(r)
+			i += size
+			continue
+		}
+		idx, err := secureRandomInt(len(chars))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		password.WriteByte(chars[idx])
+		i += size
+	}
+	if escaped {
+		return "", fmt.Errorf("pattern ends with an unfinished escape")
+	}
+
+	return password.String(), nil
+}
+
+// wordAt returns the word placeholder that s starts with, or "" if none, and
+// whether the word should be capitalized
+func wordAt(s string) (placeholder string, capital bool) {
+	switch {
+	case strings.HasPrefix(s, wordPlaceholder):
+		return wordPlaceholder, false
+	case strings.HasPrefix(s, capitalizedWordPlaceholder):
+		return capitalizedWordPlaceholder, true
+	}
+	return "", false
+}
@@ -0,0 +1,28 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MinPINLength is the shortest PIN GeneratePIN will produce
+const MinPINLength = 3
+
+// GeneratePIN creates a numeric PIN of the given length. Unlike
+// GeneratePassword it draws only from NumberChars and allows lengths down to
+// MinPINLength. The PIN is returned as a string so leading zeros are kept
+func GeneratePIN(length int) (string, error) {
+	if length < MinPINLength {
+		return "", fmt.Errorf("PIN length must be at least %d digits", MinPINLength)
+	}
+
+	var pin strings.Builder
+	for i := 0; i < length; i++ {
+		idx, err := secureRandomInt(len(NumberChars))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		pin.WriteByte(NumberChars[idx])
+	}
+	return pin.String(), nil
+}
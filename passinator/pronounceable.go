@@ -0,0 +1,121 @@
+package passinator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Letter clusters used to build pronounceable syllables. Each syllable is a
+// consonant cluster followed by a vowel cluster
+var (
+	consonantClusters = []string{
+		"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "v", "w", "z",
+		"br", "ch", "cr", "dr", "fr", "gr", "kr", "pl", "pr", "sh", "st", "th", "tr",
+	}
+	vowelClusters = []string{
+		"a", "e", "i", "o", "u",
+		"ai", "au", "ea", "ee", "ie", "oo", "ou",
+	}
+)
+
+// PronounceableConfig holds the configuration for pronounceable password
+// generation
+type PronounceableConfig struct {
+	Syllables    int
+	IncludeDigit bool
+}
+
+// GeneratePronounceable creates a password made of syllables random
+// consonant-vowel syllables, such as "tapobelu"
+func GeneratePronounceable(syllables int) (string, error) {
+	return GenerateCustomPronounceable(PronounceableConfig{Syllables: syllables})
+}
+
+// GenerateCustomPronounceable creates a pronounceable password based on the
+// provided configuration. IncludeDigit inserts a random digit between two
+// randomly chosen syllables
+func GenerateCustomPronounceable(config PronounceableConfig) (string, error) {
+	if config.Syllables < 1 {
+		return "", fmt.Errorf("pronounceable password must contain at least 1 syllable")
+	}
+
+	parts := make([]string, 0, config.Syllables+1)
+	for i := 0; i < config.Syllables; i++ {
+		syllable, err := randomSyllable()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, syllable)
+	}
+
+	if config.IncludeDigit {
+		digit, err := secureRandomInt(len(NumberChars))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		pos, err := secureRandomInt(len(parts) + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random index: %w", err)
+		}
+		parts = append(parts[:pos], append([]string{string(NumberChars[digit])}, parts[pos:]...)...)
+	}
+
+	return strings.Join(parts, ""), nil
+}
+
+// PronounceableEntropy returns the bits of entropy of a
+// GenerateCustomPronounceable password: log2 of the size of the syllable
+// inventory per syllable, plus the digit and its position with IncludeDigit.
+// A syllable is always a whole consonant cluster followed by a whole vowel
+// cluster, so different choices never spell the same password
+func PronounceableEntropy(config PronounceableConfig) float64 {
+	if config.Syllables < 1 {
+		return 0
+	}
+	bits := float64(config.Syllables) * math.Log2(float64(len(consonantClusters)*len(vowelClusters)))
+	if config.IncludeDigit {
+		bits += math.Log2(float64(len(NumberChars))) + math.Log2(float64(config.Syllables+1))
+	}
+	return bits
+}
+
+// PronounceableSyllablesFor returns the fewest syllables for which
+// PronounceableEntropy of config reaches bits, keeping config.IncludeDigit
+func PronounceableSyllablesFor(bits float64, config PronounceableConfig) int {
+	config.Syllables = 1
+	for PronounceableEntropy(config) < bits {
+		config.Syllables++
+	}
+	return config.Syllables
+}
+
+// GeneratePronounceableEntropy creates a pronounceable password of just
+// enough syllables to carry at least bits of entropy, and returns it together
+// with the entropy actually achieved
+func GeneratePronounceableEntropy(bits float64) (string, float64, error) {
+	if bits <= 0 || math.IsInf(bits, 0) || math.IsNaN(bits) {
+		return "", 0, fmt.Errorf("target entropy must be a positive number of bits")
+	}
+	config := PronounceableConfig{}
+	config.Syllables = PronounceableSyllablesFor(bits, config)
+	password, err := GenerateCustomPronounceable(config)
+	if err != nil {
+		return "", 0, err
+	}
+	return password, PronounceableEntropy(config), nil
+}
+
+// randomSyllable returns a random consonant cluster followed by a random vowel
+// cluster
+func randomSyllable() (string, error) {
+	c, err := secureRandomInt(len(consonantClusters))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random index: %w", err)
+	}
+	v, err := secureRandomInt(len(vowelClusters))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random index: %w", err)
+	}
+	return consonantClusters[c] + vowelClusters[v], nil
+}
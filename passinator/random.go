@@ -0,0 +1,171 @@
+package passinator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// randReader is the source of every random byte used outside deterministic
+// mode. It is always crypto/rand.Reader except in tests, which may replace it
+// with a fixed reader to make the output reproducible
+var randReader io.Reader = rand.Reader
+
+// secureRandomInt generates a cryptographically secure random integer in [0, max)
+func secureRandomInt(max int) (int, error) {
+	if max <= 0 {
+		return 0, fmt.Errorf("max must be positive")
+	}
+	n, err := rand.Int(randReader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+	// n is in [0, max), so it always fits in an int on every platform
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("random value out of range")
+	}
+	return int(n.Int64()), nil
+}
+
+// secureRandomBytes generates n cryptographically secure random bytes. The
+// returned slice is always fully populated unless an error is returned
+func secureRandomBytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("byte count must not be negative")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(randReader, b); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return b, nil
+}
+
+// randomBlockSize is how many bytes a randomSource reads from crypto/rand at
+// a time
+const randomBlockSize = 512
+
+// randomSource hands out uniformly distributed integers drawn from blocks of
+// crypto/rand bytes, so that generating many characters takes a handful of
+// reads instead of one per character
+type randomSource struct {
+	buf []byte
+	pos int
+}
+
+// newRandomSource creates an empty randomSource; the first block is read on
+// first use
+func newRandomSource() *randomSource {
+	return &randomSource{}
+}
+
+// nextByte returns the next random byte, refilling the block when exhausted
+func (s *randomSource) nextByte() (byte, error) {
+	if s.pos >= len(s.buf) {
+		buf, err := secureRandomBytes(randomBlockSize)
+		if err != nil {
+			return 0, err
+		}
+		wipe(s.buf)
+		s.buf, s.pos = buf, 0
+	}
+	b := s.buf[s.pos]
+	s.pos++
+	return b, nil
+}
+
+// wipe zeros the buffered block, whose bytes determined the characters that
+// were picked
+func (s *randomSource) wipe() {
+	wipe(s.buf)
+	s.pos = len(s.buf)
+}
+
+// intn returns a uniformly distributed integer in [0, max) drawn from the
+// buffered block
+func (s *randomSource) intn(max int) (int, error) {
+	return unbiasedIndexFrom(s.nextByte, max)
+}
+
+// unbiasedIndexFrom returns a uniformly distributed integer in [0, max) using
+// the random bytes returned by next. Ranges that fit in a byte use one byte
+// per draw and larger ones use four; values from the biased tail are
+// rejected and redrawn. Byte-oriented code paths should use it rather than
+// reducing a random byte with b % max.
+//
+// A byte has 256 values, so unless max divides 256 the modulo maps more byte
+// values onto the low results than the high ones: with max = 88, results
+// 0-79 are each produced by three byte values and 80-87 by only two, making
+// them 50% more likely. An attacker who knows the generator can exploit that
+// skew to search likely passwords first. Rejecting the tail of the range
+// that does not divide evenly removes the bias
+func unbiasedIndexFrom(next func() (byte, error), max int) (int, error) {
+	if max <= 0 {
+		return 0, fmt.Errorf("max must be positive")
+	}
+	if max <= 1<<8 {
+		limit := 1<<8 - (1<<8)%max
+		for {
+			b, err := next()
+			if err != nil {
+				return 0, err
+			}
+			if int(b) < limit {
+				return int(b) % max, nil
+			}
+		}
+	}
+	if uint64(max) > 1<<32 {
+		return secureRandomInt(max)
+	}
+
+	limit := uint64(1<<32) - uint64(1<<32)%uint64(max)
+	for {
+		var v uint64
+		for i := 0; i < 4; i++ {
+			b, err := next()
+			if err != nil {
+				return 0, err
+			}
+			v = v<<8 | uint64(b)
+		}
+		if v < limit {
+			return int(v % uint64(max)), nil
+		}
+	}
+}
+
+// alphanumericChars is the combined set of the lowercase, uppercase and
+// number types in the order charSet joins them, so that the fast path below
+// maps every index to the same character as the generic one
+const alphanumericChars = LowercaseChars + UppercaseChars + NumberChars
+
+// alphanumeric returns n characters drawn uniformly from alphanumericChars.
+// It is a fast path for the most common configuration: each byte of the
+// block is masked to its low 6 bits and the two values past the 62
+// characters are rejected, so about 97% of the bytes make a character
+// without going through intn and its per-draw function calls. Masking keeps
+// every value in [0, 64) equally likely, so the accepted ones are uniform
+// over the set, just as with unbiasedIndexFrom
+func (s *randomSource) alphanumeric(n int) ([]rune, error) {
+	drawn := make([]rune, 0, n)
+	for len(drawn) < n {
+		if s.pos >= len(s.buf) {
+			if _, err := s.nextByte(); err != nil {
+				return nil, fmt.Errorf("failed to generate random index: %w", err)
+			}
+			// nextByte consumed the first byte of the new block
+			s.pos--
+		}
+		for _, b := range s.buf[s.pos:] {
+			s.pos++
+			if idx := b & 0x3f; int(idx) < len(alphanumericChars) {
+				drawn = append(drawn, rune(alphanumericChars[idx]))
+				if len(drawn) == n {
+					break
+				}
+			}
+		}
+	}
+	return drawn, nil
+}
@@ -0,0 +1,42 @@
+package passinator
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Sample count and size drawn by SelfTest
+const (
+	selfTestSamples    = 4
+	selfTestSampleSize = 32
+)
+
+// SelfTest draws a few samples from the random source and reports an error
+// if they look broken: a sample of a single repeated byte, such as all zeros,
+// or two identical samples. The odds of a working source failing are about
+// 2^-250, so any failure means the source must not be used. Passing is only
+// a sanity check, not proof of good randomness
+func SelfTest() error {
+	samples := make([][]byte, 0, selfTestSamples)
+	defer func() {
+		for _, sample := range samples {
+			wipe(sample)
+		}
+	}()
+	for i := 0; i < selfTestSamples; i++ {
+		sample, err := secureRandomBytes(selfTestSampleSize)
+		if err != nil {
+			return fmt.Errorf("random source self-test failed: %w", err)
+		}
+		if bytes.Count(sample, sample[:1]) == len(sample) {
+			return fmt.Errorf("random source self-test failed: sample %d is a single repeated byte 0x%02x", i+1, sample[0])
+		}
+		for j, earlier := range samples {
+			if bytes.Equal(sample, earlier) {
+				return fmt.Errorf("random source self-test failed: samples %d and %d are identical", j+1, i+1)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// sequenceLength is the shortest run AvoidSequences treats as a sequence
+const sequenceLength = 3
+
+// sequences lists the orderings that make a run of characters guessable: the
+// alphabet, the digits, and the rows of a QWERTY keyboard with and without
+// shift. Each is also matched backwards
+var sequences = []string{
+	LowercaseChars,
+	NumberChars,
+	"1234567890-=",
+	"!@#$%^&*()_+",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+// hasSequentialRun reports whether s contains n or more consecutive
+// characters that follow one of the sequences, forwards or backwards, such as
+// "abc", "321" or "qwerty". Letters are compared case-insensitively
+func hasSequentialRun(s string, n int) bool {
+	runes := []rune(strings.Map(unicode.ToLower, s))
+	if n <= 0 {
+		return false
+	}
+	for i := 0; i+n <= len(runes); i++ {
+		if isSequence(string(runes[i : i+n])) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSequence reports whether window appears in one of the sequences,
+// forwards or backwards
+func isSequence(window string) bool {
+	reversed := []rune(window)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	for _, seq := range sequences {
+		if strings.Contains(seq, window) || strings.Contains(seq, string(reversed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsRun returns how many identical characters end at password[i]
+func endsRun(password []rune, i int) int {
+	run := 1
+	for i-run >= 0 && password[i-run] == password[i] {
+		run++
+	}
+	return run
+}
+
+// avoidSequences re-rolls the last character of every window of
+// sequenceLength characters that forms a sequence. As in limitConsecutive,
+// replacements come from the same type as the character they replace, and
+// they never create a run longer than config.MaxConsecutive or, with
+// config.NoRepeats, a repeat. Earlier characters are never changed, so
+// position 0 keeps any MustStartWithLetter guarantee
+func avoidSequences(password []rune, cats []category, config PasswordConfig, randInt randIntFunc) error {
+	offending := func(i int) bool {
+		if i >= sequenceLength-1 && isSequence(strings.Map(unicode.ToLower, string(password[i-sequenceLength+1:i+1]))) {
+			return true
+		}
+		return config.MaxConsecutive > 0 && endsRun(password, i) > config.MaxConsecutive
+	}
+
+	for i := sequenceLength - 1; i < len(password); i++ {
+		for attempt := 0; offending(i); attempt++ {
+			// A character set too small to avoid sequences fails instead of
+			// looping forever
+			if attempt >= AttemptLimit(config) {
+				return fmt.Errorf("could not avoid sequential characters with this character set: %w", ErrGenerationExhausted)
+			}
+			var candidates []rune
+			for _, c := range cats {
+				if strings.ContainsRune(c.chars, password[i]) {
+					chars := c.chars
+					if config.NoRepeats {
+						chars = removeChars(chars, string(password))
+						// The character being replaced may be reused
+						chars += string(password[i])
+					}
+					candidates = []rune(chars)
+					break
+				}
+			}
+			if len(candidates) == 0 {
+				return fmt.Errorf("no replacement available for sequential character")
+			}
+			idx, err := randInt(len(candidates))
+			if err != nil {
+				return fmt.Errorf("failed to generate random index: %w", err)
+			}
+			password[i] = candidates[idx]
+		}
+	}
+	return nil
+}
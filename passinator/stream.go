@@ -0,0 +1,79 @@
+package passinator
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// streamBufferSize is how many bytes WritePasswords collects before writing
+// them out
+const streamBufferSize = 64 * 1024
+
+// WritePasswords generates count passwords with config and writes each to w
+// followed by sep, such as "\n" for one per line. Passwords are written in
+// chunks as they are generated instead of being collected first, so even
+// millions of them need only a small, constant amount of memory. The one
+// exception is config.Unique, which has to remember every password written.
+// config.Count is ignored
+func WritePasswords(w io.Writer, config PasswordConfig, count int, sep string) error {
+	return WritePasswordsContext(context.Background(), w, config, count, sep)
+}
+
+// WritePasswordsContext works like WritePasswords but stops when ctx is
+// done, returning ctx.Err() once everything generated so far is written
+func WritePasswordsContext(ctx context.Context, w io.Writer, config PasswordConfig, count int, sep string) error {
+	// Failing before anything is written where possible
+	if err := ValidateBatch(config, count); err != nil {
+		return err
+	}
+
+	src := newRandomSource()
+	defer src.wipe()
+	// A buffer of our own rather than a bufio.Writer, so that it can be
+	// zeroed once the passwords are written
+	buf := make([]byte, 0, streamBufferSize)
+	defer func() { wipe(buf) }()
+	flush := func() error {
+		_, err := w.Write(buf)
+		clear(buf)
+		buf = buf[:0]
+		return err
+	}
+
+	var seen map[string]bool
+	if config.Unique {
+		seen = make(map[string]bool)
+	}
+	for attempts, written := 0, 0; written < count; attempts++ {
+		if err := ctx.Err(); err != nil {
+			if flushErr := flush(); flushErr != nil {
+				return flushErr
+			}
+			return err
+		}
+		if attempts >= BatchAttempts(config, count) {
+			return fmt.Errorf("could only generate %d distinct passwords out of %d requested: %w", written, count, ErrGenerationExhausted)
+		}
+		password, err := generate(config, src.intn, src)
+		if err != nil {
+			return err
+		}
+		if seen != nil {
+			if seen[password] {
+				continue
+			}
+			seen[password] = true
+		}
+		if len(buf)+len(password)+len(sep) > cap(buf) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		// A password longer than the buffer still fits in a larger one
+		buf = append(buf, password...)
+		buf = append(buf, sep...)
+		written++
+	}
+	return flush()
+}
@@ -0,0 +1,41 @@
+package passinator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Token encodings supported by GenerateToken
+const (
+	EncodingHex       = "hex"
+	EncodingBase64    = "base64"
+	EncodingBase64URL = "base64url"
+)
+
+// GenerateToken creates byteLen random bytes and returns them encoded as hex,
+// standard base64 or unpadded URL-safe base64, for use as API keys and tokens
+func GenerateToken(byteLen int, encoding string) (string, error) {
+	if byteLen <= 0 {
+		return "", fmt.Errorf("token length must be at least 1 byte")
+	}
+
+	var encode func([]byte) string
+	switch encoding {
+	case EncodingHex:
+		encode = hex.EncodeToString
+	case EncodingBase64:
+		encode = base64.StdEncoding.EncodeToString
+	case EncodingBase64URL:
+		encode = base64.RawURLEncoding.EncodeToString
+	default:
+		return "", fmt.Errorf("unknown token encoding %q (use %s, %s or %s)", encoding, EncodingHex, EncodingBase64, EncodingBase64URL)
+	}
+
+	b, err := secureRandomBytes(byteLen)
+	if err != nil {
+		return "", err
+	}
+	defer wipe(b)
+	return encode(b), nil
+}
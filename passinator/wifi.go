@@ -0,0 +1,57 @@
+package passinator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Length limits of a WPA/WPA2 passphrase, and the length GenerateWiFi and the
+// -wifi preset use by default
+const (
+	MinWiFiLength     = 8
+	MaxWiFiLength     = 63
+	DefaultWiFiLength = 20
+)
+
+// WiFiConfig returns the settings of a Wi-Fi passphrase of the given length:
+// letters, digits and SpecialCharsWiFi
+func WiFiConfig(length int) PasswordConfig {
+	return PasswordConfig{
+		Length:          length,
+		UseLowercase:    true,
+		UseUppercase:    true,
+		UseNumbers:      true,
+		UseSpecialChars: true,
+		SpecialCharset:  SpecialCharsWiFi,
+		Count:           1,
+	}
+}
+
+// ValidateWiFiConfig checks that config can only produce a valid WPA
+// passphrase: MinWiFiLength to MaxWiFiLength characters of printable ASCII,
+// at every length of a MaxLength range. Spaces are rejected too, since many
+// devices trim or mistype them
+func ValidateWiFiConfig(config PasswordConfig) error {
+	if config.Length < MinWiFiLength || max(config.Length, config.MaxLength) > MaxWiFiLength {
+		return fmt.Errorf("Wi-Fi passphrase length must be between %d and %d characters", MinWiFiLength, MaxWiFiLength)
+	}
+	if config.UseUnicode {
+		return fmt.Errorf("Wi-Fi passphrases must be ASCII, so Unicode characters cannot be used")
+	}
+	for _, chars := range []string{config.CustomCharset, config.SpecialCharset} {
+		if i := strings.IndexFunc(chars, func(r rune) bool { return r <= ' ' || r > '~' }); i >= 0 {
+			return fmt.Errorf("Wi-Fi passphrases must be printable ASCII without spaces, found %q", chars[i:i+1])
+		}
+	}
+	return nil
+}
+
+// GenerateWiFi creates a Wi-Fi passphrase of the given length using
+// WiFiConfig
+func GenerateWiFi(length int) (string, error) {
+	config := WiFiConfig(length)
+	if err := ValidateWiFiConfig(config); err != nil {
+		return "", err
+	}
+	return GeneratePassword(config)
+}
@@ -0,0 +1,18 @@
+package passinator
+
+// wipe overwrites b, including any spare capacity, with zeros so that secret
+// material does not linger in memory after use
+//
+// This is best effort only. Go strings are immutable and cannot be wiped, so
+// the returned password itself stays in memory until the garbage collector
+// reuses it, and the runtime may already have copied a buffer elsewhere when
+// growing a slice or moving a stack. Wiping still shortens the time the
+// intermediate buffers under our control hold the secret
+func wipe(b []byte) {
+	clear(b[:cap(b)])
+}
+
+// wipeRunes is wipe for rune buffers
+func wipeRunes(r []rune) {
+	clear(r[:cap(r)])
+}
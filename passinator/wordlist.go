@@ -0,0 +1,91 @@
+package passinator
+
+import (
+	"embed"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultWordlist names the wordlist used when none is selected
+const DefaultWordlist = "en"
+
+// wordlistFS holds the embedded wordlists
+//
+//   - eff_large.txt is the EFF large diceware wordlist, one "roll<TAB>word"
+//     entry per line: https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//   - bip39_spanish.txt and bip39_french.txt are the BIP-39 wordlists of
+//     2048 words each, converted to NFC so accented words match what a
+//     keyboard produces: https://github.com/bitcoin/bips/tree/master/bip-0039
+//   - german.txt is a list of 2176 common German words of 3 to 8 letters,
+//     lowercase and in NFC like the BIP-39 lists. BIP-39 has no German list,
+//     so this one was written for the project. It leaves out words with ß,
+//     which not every keyboard can type
+//
+//go:embed wordlists/*.txt
+var wordlistFS embed.FS
+
+// wordlistFiles maps each wordlist name to its file in wordlistFS
+var wordlistFiles = map[string]string{
+	"de": "wordlists/german.txt",
+	"en": "wordlists/eff_large.txt",
+	"es": "wordlists/bip39_spanish.txt",
+	"fr": "wordlists/bip39_french.txt",
+}
+
+// wordlistCache holds the wordlists already parsed by loadWordlist
+var (
+	wordlistMu    sync.Mutex
+	wordlistCache = map[string][]string{}
+)
+
+// Wordlists returns the names of the embedded wordlists in sorted order
+func Wordlists() []string {
+	names := make([]string, 0, len(wordlistFiles))
+	for name := range wordlistFiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// loadWordlist returns the words of the embedded wordlist called name, or of
+// DefaultWordlist when name is empty. The result is shared and must not be
+// modified
+func loadWordlist(name string) ([]string, error) {
+	if name == "" {
+		name = DefaultWordlist
+	}
+	file, ok := wordlistFiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist %q (available: %s)", name, strings.Join(Wordlists(), ", "))
+	}
+
+	wordlistMu.Lock()
+	defer wordlistMu.Unlock()
+	if words, ok := wordlistCache[name]; ok {
+		return words, nil
+	}
+	data, err := wordlistFS.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlist %q: %w", name, err)
+	}
+	words := parseWordlist(string(data))
+	wordlistCache[name] = words
+	return words, nil
+}
+
+// parseWordlist extracts the words from a diceware-formatted list, ignoring
+// the dice roll column if present
+func parseWordlist(data string) []string {
+	var words []string
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		words = append(words, fields[len(fields)-1])
+	}
+	return words
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"pass-inator/passinator"
+)
+
+// passphraseFlags holds the flags that configure passphrase generation
+type passphraseFlags struct {
+	config         passinator.PassphraseConfig
+	memorable      bool
+	inject         bool
+	wordNumberWord bool
+	minLength      int
+}
+
+// addPassphraseFlags registers the passphrase flags on fs
+func addPassphraseFlags(fs *flag.FlagSet) *passphraseFlags {
+	p := &passphraseFlags{}
+	fs.IntVar(&p.config.Words, "words", 6, "number of words in a passphrase")
+	fs.StringVar(&p.config.Separator, "sep", "-", "separator placed between passphrase words")
+	fs.BoolVar(&p.config.Capitalize, "capitalize", false, "capitalize the first letter of each passphrase word (same as -caps first)")
+	fs.StringVar(&p.config.Caps, "caps", "", "passphrase capitalization `scheme`: none, first (Title Case), all (ALL CAPS) or random (each letter, adding about 1 bit per letter) (default none)")
+	fs.BoolVar(&p.config.AppendNumber, "append-number", false, "append a random digit to the passphrase")
+	fs.BoolVar(&p.inject, "passphrase-inject", false, "insert a random digit and a random special character at random positions of the passphrase")
+	fs.StringVar(&p.config.Wordlist, "wordlist", passinator.DefaultWordlist, "embedded wordlist to draw passphrase words from: "+strings.Join(passinator.Wordlists(), ", "))
+	fs.IntVar(&p.minLength, "min-length", 0, "keep adding passphrase words past -words until the passphrase has at least `N` characters")
+	fs.BoolVar(&p.wordNumberWord, "word-number-word", false, "generate a readable name of two words joined by a digit, e.g. correct7horse")
+	fs.BoolVar(&p.memorable, "memorable", false, fmt.Sprintf("generate title-cased words plus a number and symbol, e.g. Tiger-Cloud-42! (-words defaults to %d)", defaultMemorableWords))
+	return p
+}
+
+// run generates and prints count passphrases as configured by the flags
+// parsed on fs
+func (p *passphraseFlags) run(fs *flag.FlagSet, count int, guessRate float64, opts outputOptions) {
+	if p.wordNumberWord {
+		// Always two words and a digit, exactly as the pattern spells them
+		rejectFlagsWith(fs, "word-number-word", "words", "sep", "caps", "capitalize", "append-number", "passphrase-inject", "min-length")
+		runPattern(passinator.WordNumberWordPattern, p.config.Wordlist, count, opts)
+		return
+	}
+	if p.memorable {
+		// The memorable format fixes its own separator, capitals and suffix
+		rejectFlagsWith(fs, "memorable", "caps", "capitalize", "sep", "append-number", "passphrase-inject", "min-length")
+		words := defaultMemorableWords
+		if isFlagSet(fs, "words") {
+			words = p.config.Words
+		}
+		runMemorable(p.config.Wordlist, words, count, guessRate, opts)
+		return
+	}
+	config := p.config
+	if p.inject {
+		config.InjectDigits, config.InjectSpecials = 1, 1
+	}
+	if p.minLength != 0 {
+		runPassphraseMinLen(config, p.minLength, count, guessRate, opts)
+		return
+	}
+	runPassphrase(config, count, guessRate, opts)
+}
+
+// rejectFlagsWith exits with an error when any of the flags names was given
+// on fs together with the flag mode, which would ignore them
+func rejectFlagsWith(fs *flag.FlagSet, mode string, names ...string) {
+	for _, name := range names {
+		if isFlagSet(fs, name) {
+			fmt.Printf("Error: -%s cannot be combined with -%s\n", name, mode)
+			os.Exit(exitError)
+		}
+	}
+}
+
+// passphraseCommand implements the passphrase command
+func passphraseCommand(args []string) {
+	fs := flag.NewFlagSet("passphrase", flag.ExitOnError)
+	phrase := addPassphraseFlags(fs)
+	count := fs.Int("count", 1, "number of passphrases to generate")
+	pattern := fs.String("pattern", "", "generate from a `pattern` mixing words and characters, e.g. word-word-##-$ (word/Word=wordlist word, A=upper, a=lower, #=digit, $=special)")
+	guessRate := fs.Float64("guess-rate", passinator.DefaultGuessesPerSecond, "attacker guesses per second assumed by the crack time estimate")
+	opts := addOutputFlags(fs)
+	selfTest := addSelfTestFlag(fs)
+	fs.Usage = commandUsage(fs, "", "Generates a diceware-style passphrase from an embedded wordlist.")
+	fs.Parse(args)
+
+	prepareOutputOptions(opts)
+	runSelfTest(*selfTest, *opts)
+	if *pattern != "" {
+		runPattern(*pattern, phrase.config.Wordlist, *count, *opts)
+		return
+	}
+	phrase.run(fs, *count, *guessRate, *opts)
+}
+
+// runPassphrase generates and prints count passphrases followed by their
+// strength report
+func runPassphrase(config passinator.PassphraseConfig, count int, guessRate float64, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating passphrase: passphrase count must be at least 1")
+		os.Exit(exitError)
+	}
+	entropy, err := passinator.PassphraseEntropy(config)
+	if err != nil {
+		fmt.Printf("Error generating passphrase: %v\n", err)
+		os.Exit(exitError)
+	}
+	passphrases, err := generatePassphrases(config, count)
+	if err != nil {
+		fmt.Printf("Error generating passphrase: %v\n", err)
+		os.Exit(exitError)
+	}
+	printResults(passphrases, opts)
+	if !opts.quiet {
+		fmt.Fprintln(os.Stderr, formatEntropy(entropy, guessRate))
+	}
+	clearEphemeral(opts)
+}
+
+// generatePassphrases generates count passphrases configured by config
+func generatePassphrases(config passinator.PassphraseConfig, count int) ([]string, error) {
+	passphrases := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		passphrase, err := passinator.GenerateCustomPassphrase(config)
+		if err != nil {
+			return nil, err
+		}
+		passphrases = append(passphrases, passphrase)
+	}
+	return passphrases, nil
+}
+
+// runPassphraseMinLen generates and prints count passphrases of at least
+// minLen characters, followed by the number of words used and the strength
+// report
+func runPassphraseMinLen(config passinator.PassphraseConfig, minLen, count int, guessRate float64, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating passphrase: passphrase count must be at least 1")
+		os.Exit(exitError)
+	}
+	entropy, err := passinator.PassphraseMinLenEntropy(config, minLen)
+	if err != nil {
+		fmt.Printf("Error generating passphrase: %v\n", err)
+		os.Exit(exitError)
+	}
+	passphrases := make([]string, 0, count)
+	fewest, most := 0, 0
+	for i := 0; i < count; i++ {
+		passphrase, words, err := passinator.GeneratePassphraseMinLenFrom(config, minLen)
+		if err != nil {
+			fmt.Printf("Error generating passphrase: %v\n", err)
+			os.Exit(exitError)
+		}
+		passphrases = append(passphrases, passphrase)
+		if i == 0 {
+			fewest, most = words, words
+		}
+		fewest, most = min(fewest, words), max(most, words)
+	}
+	printResults(passphrases, opts)
+	if !opts.quiet {
+		if fewest == most {
+			fmt.Fprintf(os.Stderr, "Words used: %d\n", fewest)
+		} else {
+			fmt.Fprintf(os.Stderr, "Words used: %d to %d\n", fewest, most)
+		}
+		fmt.Fprintln(os.Stderr, formatEntropy(entropy, guessRate))
+	}
+	clearEphemeral(opts)
+}
+
+// runMemorable generates and prints count memorable passphrases followed by
+// their strength report
+func runMemorable(wordlist string, words, count int, guessRate float64, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating passphrase: passphrase count must be at least 1")
+		os.Exit(exitError)
+	}
+	entropy, err := passinator.MemorableEntropyFrom(wordlist, words)
+	if err != nil {
+		fmt.Printf("Error generating passphrase: %v\n", err)
+		os.Exit(exitError)
+	}
+	passphrases := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		passphrase, err := passinator.GenerateMemorableFrom(wordlist, words)
+		if err != nil {
+			fmt.Printf("Error generating passphrase: %v\n", err)
+			os.Exit(exitError)
+		}
+		passphrases = append(passphrases, passphrase)
+	}
+	printResults(passphrases, opts)
+	if !opts.quiet {
+		fmt.Fprintln(os.Stderr, formatEntropy(entropy, guessRate))
+	}
+	clearEphemeral(opts)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// natoAlphabet holds the NATO phonetic alphabet code word of each letter, in
+// alphabetical order
+var natoAlphabet = [26]string{
+	"alfa", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel",
+	"india", "juliett", "kilo", "lima", "mike", "november", "oscar", "papa",
+	"quebec", "romeo", "sierra", "tango", "uniform", "victor", "whiskey",
+	"x-ray", "yankee", "zulu",
+}
+
+// phoneticNames spells out the digits and the symbols of every built-in
+// special character set
+var phoneticNames = map[rune]string{
+	'0': "zero", '1': "one", '2': "two", '3': "three", '4': "four",
+	'5': "five", '6': "six", '7': "seven", '8': "eight", '9': "nine",
+	'!': "exclamation", '@': "at", '#': "hash", '$': "dollar", '%': "percent",
+	'^': "caret", '&': "ampersand", '*': "asterisk", '(': "open-paren", ')': "close-paren",
+	'_': "underscore", '+': "plus", '-': "dash", '=': "equals", '[': "open-bracket",
+	']': "close-bracket", '{': "open-brace", '}': "close-brace", '|': "pipe", ';': "semicolon",
+	':': "colon", ',': "comma", '.': "period", '<': "less-than", '>': "greater-than",
+	'?': "question-mark", '~': "tilde", '/': "slash", '\\': "backslash", '\'': "apostrophe",
+	'"': "quote", '`': "backtick", ' ': "space",
+}
+
+// phoneticSpell returns the spoken form of every character of s: NATO code
+// words for ASCII letters, in upper case for upper-case letters, and names
+// for digits and symbols. Any other character is returned as is
+func phoneticSpell(s string) []string {
+	words := make([]string, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			words = append(words, natoAlphabet[r-'a'])
+		case r >= 'A' && r <= 'Z':
+			words = append(words, strings.ToUpper(natoAlphabet[unicode.ToLower(r)-'a']))
+		default:
+			if name, ok := phoneticNames[r]; ok {
+				words = append(words, name)
+			} else {
+				words = append(words, string(r))
+			}
+		}
+	}
+	return words
+}
+
+// printPhonetic prints the phoneticSpell of each result on its own line
+func printPhonetic(results []string) {
+	for _, result := range results {
+		fmt.Println(strings.Join(phoneticSpell(result), " "))
+	}
+}
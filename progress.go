@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressInterval is the least time between two redraws of the progress
+// line, and how long a batch runs before the line first appears
+const progressInterval = 250 * time.Millisecond
+
+// progress draws a "Generated 5000/20000 (25%)" line on stderr while a large
+// batch is generated. A nil *progress draws nothing, so callers need not
+// check whether it is enabled
+type progress struct {
+	total int
+	last  time.Time
+	shown bool
+}
+
+// newProgress returns a progress line for a batch of total passwords, or nil
+// when it would not be seen or would get in the way: with -quiet, for a
+// single password, or when stderr is not a terminal
+func newProgress(total int, opts outputOptions) *progress {
+	if opts.quiet || total <= 1 || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return &progress{total: total, last: time.Now()}
+}
+
+// update records that done passwords have been generated, redrawing the line
+// at most once per progressInterval
+func (p *progress) update(done int) {
+	if p == nil {
+		return
+	}
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.shown = true
+		p.draw(done)
+	}
+}
+
+// finish draws the final count and ends the line, if it was ever shown, so
+// that later messages start on a line of their own
+func (p *progress) finish(done int) {
+	if p == nil || !p.shown {
+		return
+	}
+	p.draw(done)
+	fmt.Fprintln(os.Stderr)
+}
+
+// draw overwrites the progress line with done out of p.total
+func (p *progress) draw(done int) {
+	fmt.Fprintf(os.Stderr, "\rGenerated %d/%d (%d%%)", done, p.total, done*100/p.total)
+}
+
+// progressWriter passes writes through to w and reports the number of
+// newline-terminated passwords written so far to p
+type progressWriter struct {
+	w     io.Writer
+	p     *progress
+	count int
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.count += bytes.Count(b[:n], []byte{'\n'})
+	pw.p.update(pw.count)
+	return n, err
+}
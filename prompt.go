@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"pass-inator/passinator"
+)
+
+// stdin is shared by every prompt so that input buffered by one read is not
+// lost to the next
+var stdin = bufio.NewReader(os.Stdin)
+
+// errInputClosed is returned by the prompts when stdin ends, e.g. after
+// Ctrl-D or once piped answers run out, since no answer will ever arrive
+var errInputClosed = errors.New("input closed")
+
+// readUserInput prints prompt and reads a line of input, returning
+// errInputClosed once stdin has ended. A last line without a newline is
+// still returned as input
+func readUserInput(prompt string) (string, error) {
+	fmt.Print(prompt)
+	input, err := stdin.ReadString('\n')
+	if err != nil && input == "" {
+		// Finish the prompt line so the caller's message starts afresh
+		fmt.Println()
+		if errors.Is(err, io.EOF) {
+			return "", errInputClosed
+		}
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// readSecret reads a line without echoing it when stdin is a terminal, so that
+// an existing password or master secret never appears on screen. The prompt
+// goes to stderr to keep stdout limited to results. Piped input is read as
+// an ordinary line
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		secret, err := term.ReadPassword(fd)
+		// The Enter key was not echoed either
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(secret), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readYesNoDefault asks a yes/no question, showing the default answer in
+// upper case, and returns def when the user just presses Enter
+func readYesNoDefault(prompt string, def bool) (bool, error) {
+	options := "(y/N)"
+	if def {
+		options = "(Y/n)"
+	}
+	for {
+		input, err := readUserInput(fmt.Sprintf("%s %s: ", prompt, options))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(input) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Println("Please enter 'y' or 'n'")
+	}
+}
+
+// readInt asks for a whole number of at least min, repeating the question
+// until a valid one is entered, and returns def when the user just presses
+// Enter
+func readInt(prompt string, min, def int) (int, error) {
+	for {
+		input, err := readUserInput(prompt)
+		if err != nil {
+			return 0, err
+		}
+		if input == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(input)
+		if err == nil && n >= min {
+			return n, nil
+		}
+		fmt.Printf("Please enter a whole number of at least %d\n", min)
+	}
+}
+
+// promptConfig greets the user and interactively asks for the password
+// configuration. It fails with errInputClosed if stdin ends before every
+// question is answered
+func promptConfig() (passinator.PasswordConfig, error) {
+	fmt.Println("Welcome to Pass-inator - Your Secure Password Generator")
+	fmt.Println("-----------------------------------------------------")
+	return promptPasswordSettings(passinator.PasswordConfig{
+		Count:           1,
+		Length:          defaultPasswordLength,
+		UseLowercase:    true,
+		UseUppercase:    true,
+		UseNumbers:      true,
+		UseSpecialChars: true,
+	})
+}
+
+// promptPasswordSettings asks the questions of promptConfig, without its
+// greeting, offering the length and character types of config as the
+// defaults. It returns config with the answers filled in and its other
+// settings unchanged
+func promptPasswordSettings(config passinator.PasswordConfig) (passinator.PasswordConfig, error) {
+	var err error
+	config.Length, err = readInt(fmt.Sprintf("Enter password length (minimum %d) [%d]: ", passinator.MinPasswordLength, config.Length),
+		passinator.MinPasswordLength, config.Length)
+	if err != nil {
+		return config, err
+	}
+
+	questions := []struct {
+		prompt string
+		answer *bool
+	}{
+		{"Include lowercase letters?", &config.UseLowercase},
+		{"Include uppercase letters?", &config.UseUppercase},
+		{"Include numbers?", &config.UseNumbers},
+		{"Include special characters?", &config.UseSpecialChars},
+	}
+	for _, q := range questions {
+		if *q.answer, err = readYesNoDefault(q.prompt, *q.answer); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"pass-inator/passinator"
+)
+
+// pwnedRangeURL is the Have I Been Pwned range API. Only the first five hex
+// characters of the SHA-1 hash are ever sent (k-anonymity)
+const pwnedRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// checkPwned reports whether password appears in the Have I Been Pwned breach
+// corpus. Neither the password nor its full hash leave the machine: the API
+// returns every hash suffix sharing the 5 character prefix and the match is
+// done locally
+func checkPwned(client *http.Client, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequest(http.MethodGet, pwnedRangeURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides the real number of matches from anyone observing traffic
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// Padding entries have a count of zero
+		if ok && candidate == suffix && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// replacePwned checks every password against Have I Been Pwned and
+// replaces any found in a breach with a call to regenerate, giving up with
+// ErrGenerationExhausted after maxAttempts replacements of the same one.
+// With unique, a replacement must also differ from the rest of the batch and
+// is regenerated up to maxAttempts times until it does. With maxAttempts 0 a
+// breached password is only reported. Lookup failures only produce a warning
+// so that generation still works offline
+func replacePwned(client *http.Client, passwords []string, regenerate func() (string, error), unique bool, maxAttempts int) ([]string, error) {
+	seen := make(map[string]bool)
+	if unique {
+		for _, password := range passwords {
+			seen[password] = true
+		}
+	}
+	for i := range passwords {
+		for attempt := 0; ; attempt++ {
+			pwned, err := checkPwned(client, passwords[i])
+			if err != nil {
+				// Most likely offline, so don't repeat the lookup for every password
+				fmt.Fprintf(os.Stderr, "Warning: could not check passwords against Have I Been Pwned: %v\n", err)
+				return passwords, nil
+			}
+			if !pwned {
+				break
+			}
+			if maxAttempts == 0 {
+				fmt.Fprintln(os.Stderr, "Warning: password appears in a known data breach and could not be replaced")
+				break
+			}
+			if attempt >= maxAttempts {
+				return nil, fmt.Errorf("no password outside known data breaches found in %d attempts: %w", maxAttempts, passinator.ErrGenerationExhausted)
+			}
+
+			fmt.Fprintln(os.Stderr, "Warning: password appears in a known data breach, regenerating")
+			password, err := regenerate()
+			for tries := 1; err == nil && seen[password]; tries++ {
+				if tries >= maxAttempts {
+					return nil, fmt.Errorf("no distinct replacement for a breached password found in %d attempts: %w", maxAttempts, passinator.ErrGenerationExhausted)
+				}
+				password, err = regenerate()
+			}
+			if err != nil {
+				return nil, err
+			}
+			if unique {
+				delete(seen, passwords[i])
+				seen[password] = true
+			}
+			passwords[i] = password
+		}
+	}
+	return passwords, nil
+}
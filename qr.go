@@ -0,0 +1,43 @@
+//go:build qr
+
+package main
+
+import (
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the number of blank modules drawn around the code, which
+// scanners need to find its edges
+const qrQuietZone = 2
+
+// renderQR renders s as a QR code made of Unicode half blocks, two modules
+// per character row. Light modules are drawn with the terminal foreground
+// colour, which suits the usual light-on-dark terminal
+func renderQR(s string) (string, error) {
+	code, err := qr.Encode(s, qr.M)
+	if err != nil {
+		return "", err
+	}
+
+	light := func(x, y int) bool { return !code.Black(x, y) }
+	var out strings.Builder
+	for y := -qrQuietZone; y < code.Size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < code.Size+qrQuietZone; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				out.WriteRune('█')
+			case top:
+				out.WriteRune('▀')
+			case bottom:
+				out.WriteRune('▄')
+			default:
+				out.WriteRune(' ')
+			}
+		}
+		out.WriteByte('\n')
+	}
+	return out.String(), nil
+}
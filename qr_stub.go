@@ -0,0 +1,11 @@
+//go:build !qr
+
+package main
+
+import "errors"
+
+// renderQR reports that QR support was not compiled in. Building with
+// -tags qr adds it along with its rsc.io/qr dependency
+func renderQR(s string) (string, error) {
+	return "", errors.New("QR output is not available in this build; rebuild with -tags qr")
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"pass-inator/passinator"
+)
+
+// addSelfTestFlag registers the -self-test flag on fs
+func addSelfTestFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("self-test", false, "check that the system random number generator works before generating anything")
+}
+
+// runSelfTest runs passinator.SelfTest when enabled and exits if the random
+// source looks broken, since nothing it produces could be trusted
+func runSelfTest(enabled bool, opts outputOptions) {
+	if !enabled {
+		return
+	}
+	if err := passinator.SelfTest(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\nRefusing to generate anything with a broken random source.\n", err)
+		os.Exit(exitError)
+	}
+	if !opts.quiet {
+		fmt.Fprintln(os.Stderr, "Random source self-test passed")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// secretStore is an OS credential store that -store can save a generated
+// secret in. New backends implement it and are added to secretStores
+type secretStore interface {
+	// name is how the store is referred to in messages
+	name() string
+	// store saves secret under service and account, replacing any secret
+	// already saved there
+	store(service, account, secret string) error
+}
+
+// secretStores maps each supported platform to its credential store
+var secretStores = map[string]secretStore{
+	"darwin": keychainStore{},
+	"linux":  secretToolStore{},
+}
+
+// platformStore returns the credential store of the running platform
+func platformStore() (secretStore, error) {
+	s, ok := secretStores[runtime.GOOS]
+	if !ok {
+		return nil, fmt.Errorf("-store is not supported on %s", runtime.GOOS)
+	}
+	return s, nil
+}
+
+// parseStoreTarget splits a -store value of the form service/account. The
+// account is what follows the last slash, so the service may contain slashes
+// itself, as a URL does
+func parseStoreTarget(target string) (service, account string, err error) {
+	i := strings.LastIndex(target, "/")
+	if i <= 0 || i == len(target)-1 {
+		return "", "", fmt.Errorf("-store %q must have the form service/account", target)
+	}
+	return target[:i], target[i+1:], nil
+}
+
+// storeSecret saves secret in the platform credential store under the
+// service/account given by target, and returns the store's name
+func storeSecret(target, secret string) (string, error) {
+	service, account, err := parseStoreTarget(target)
+	if err != nil {
+		return "", err
+	}
+	s, err := platformStore()
+	if err != nil {
+		return "", err
+	}
+	if err := s.store(service, account, secret); err != nil {
+		return "", err
+	}
+	return s.name(), nil
+}
+
+// runStoreTool runs the credential store command args, feeding it stdin, and
+// includes whatever it printed in the error when it fails
+func runStoreTool(stdin []byte, args ...string) error {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return fmt.Errorf("%s not found: %w", args[0], err)
+	}
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// keychainStore saves secrets in the macOS login keychain with security(1)
+type keychainStore struct{}
+
+func (keychainStore) name() string { return "the macOS Keychain" }
+
+// store runs security add-generic-password, whose -U updates an existing
+// item. security only takes the secret as an argument, so it is briefly
+// visible to other processes of the same user
+func (keychainStore) store(service, account, secret string) error {
+	return runStoreTool(nil, "security", "add-generic-password", "-U", "-s", service, "-a", account, "-w", secret)
+}
+
+// secretToolStore saves secrets in the freedesktop Secret Service (GNOME
+// Keyring, KWallet) with secret-tool(1) from libsecret
+type secretToolStore struct{}
+
+func (secretToolStore) name() string { return "the Secret Service" }
+
+// store runs secret-tool store, which reads the secret from stdin and
+// replaces an item with the same attributes
+func (secretToolStore) store(service, account, secret string) error {
+	// Zeroed once the tool has read it, as in copyToClipboard
+	data := []byte(secret)
+	defer clear(data)
+	return runStoreTool(data, "secret-tool", "store", "--label", service+"/"+account, "service", service, "account", account)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"pass-inator/passinator"
+)
+
+// tokenFlags holds the flags that configure token generation
+type tokenFlags struct {
+	bytes    int
+	encoding string
+}
+
+// addTokenFlags registers the token flags on fs
+func addTokenFlags(fs *flag.FlagSet) *tokenFlags {
+	t := &tokenFlags{}
+	fs.IntVar(&t.bytes, "bytes", 32, "number of random bytes in a token")
+	fs.StringVar(&t.encoding, "encoding", passinator.EncodingHex, "token encoding: hex, base64 or base64url (unpadded)")
+	return t
+}
+
+// tokenCommand implements the token command
+func tokenCommand(args []string) {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	token := addTokenFlags(fs)
+	count := fs.Int("count", 1, "number of tokens to generate")
+	opts := addOutputFlags(fs)
+	selfTest := addSelfTestFlag(fs)
+	fs.Usage = commandUsage(fs, "", "Generates random bytes encoded for use as an API key or secret.")
+	fs.Parse(args)
+
+	prepareOutputOptions(opts)
+	runSelfTest(*selfTest, *opts)
+	runToken(token.bytes, token.encoding, *count, false, *count, *opts)
+}
+
+// runToken generates and prints count tokens, all distinct when unique is
+// set and found within attempts tries
+func runToken(byteLen int, encoding string, count int, unique bool, attempts int, opts outputOptions) {
+	if count <= 0 {
+		fmt.Println("Error generating token: token count must be at least 1")
+		os.Exit(exitError)
+	}
+	if unique {
+		if err := checkUniqueKeyspace(count, float64(byteLen)*8, "tokens"); err != nil {
+			fmt.Printf("Error generating token: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+	tokens, err := generateBatch(count, unique, attempts, func() (string, error) {
+		return passinator.GenerateToken(byteLen, encoding)
+	})
+	if err != nil {
+		fmt.Printf("Error generating token: %v\n", err)
+		os.Exit(exitError)
+	}
+	printResults(tokens, opts)
+	clearEphemeral(opts)
+}
+
+// generateTokens generates count tokens of byteLen random bytes each
+func generateTokens(byteLen int, encoding string, count int) ([]string, error) {
+	tokens := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		token, err := passinator.GenerateToken(byteLen, encoding)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}